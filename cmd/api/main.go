@@ -40,12 +40,13 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 func main() {
 
 	server := server.NewServer()
+	server.Start(context.Background())
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
 
 	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(server, done)
+	go gracefulShutdown(server.Server, done)
 
 	err := server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
@@ -0,0 +1,102 @@
+// Command gendataset regenerates the embedded offline country dataset from
+// a provider dump, either a saved file or fetched live from the upstream.
+//
+//	go run ./cmd/gendataset -in dump.json
+//	go run ./cmd/gendataset -url https://www.apicountries.com/countries
+package main
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/httpclient"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+func main() {
+	in := flag.String("in", "", "read the provider dump from this file instead of fetching it")
+	url := flag.String("url", externalapi.DefaultBaseURL+"/countries", "upstream endpoint listing every country")
+	out := flag.String("out", "internal/dataset/countries.json", "where to write the dataset")
+	flag.Parse()
+
+	dump, err := readDump(*in, *url)
+	if err != nil {
+		log.Fatalf("error reading provider dump: %v", err)
+	}
+
+	countries, err := convert(dump)
+	if err != nil {
+		log.Fatalf("error converting provider dump: %v", err)
+	}
+
+	data, err := json.MarshalIndent(countries, "", "  ")
+	if err != nil {
+		log.Fatalf("error handling JSON marshal. Err: %v", err)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+		log.Fatalf("error writing dataset: %v", err)
+	}
+	log.Printf("wrote %d countries to %s", len(countries), *out)
+}
+
+func readDump(path, url string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	if path != "" {
+		return os.ReadFile(path)
+	}
+
+	cfg := httpclient.DefaultConfig()
+	cfg.Timeout = time.Minute
+	client, err := httpclient.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api returned status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// convert decodes the dump, checks every record is usable and returns the
+// countries sorted by name so regenerating gives a stable diff.
+func convert(dump []byte) ([]externalapi.Country, error) {
+	var records []externalapi.CountryAPIResponse
+	if err := json.Unmarshal(dump, &records); err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("dump holds no countries")
+	}
+
+	seen := make(map[string]bool, len(records))
+	countries := make([]externalapi.Country, 0, len(records))
+	for i, r := range records {
+		if r.Name == "" || r.Alpha3Code == "" {
+			return nil, fmt.Errorf("record %d is missing its name or alpha-3 code", i)
+		}
+		if seen[r.Alpha3Code] {
+			return nil, fmt.Errorf("duplicate country %s", r.Alpha3Code)
+		}
+		seen[r.Alpha3Code] = true
+		countries = append(countries, r.Country())
+	}
+
+	sort.Slice(countries, func(i, j int) bool {
+		return countries[i].Name < countries[j].Name
+	})
+	return countries, nil
+}
@@ -0,0 +1,59 @@
+// Command mockupstream serves the apicountries.com and REST Countries APIs
+// locally, from the embedded dataset or recorded fixtures, optionally
+// injecting latency and failures.
+//
+//	go run ./cmd/mockupstream -addr :9090 -latency 200ms -error-rate 0.1
+//	UPSTREAM_BASE_URL=http://localhost:9090 go run ./cmd/api/main.go
+package main
+
+import (
+	"CountrySearch/internal/dataset"
+	"CountrySearch/internal/httpfixture"
+	"CountrySearch/internal/mockupstream"
+	"flag"
+	"log"
+	"net/http"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	fixtures := flag.String("fixtures", "", "serve the recorded fixtures in this directory instead of the embedded dataset")
+	latency := flag.Duration("latency", 0, "delay added to every response")
+	jitter := flag.Duration("jitter", 0, "up to this much extra random delay")
+	errorRate := flag.Float64("error-rate", 0, "fraction of requests answered with 500")
+	malformedRate := flag.Float64("malformed-rate", 0, "fraction of requests answered with truncated JSON")
+	throttleRate := flag.Float64("throttle-rate", 0, "fraction of requests answered with 429")
+	seed := flag.Uint64("seed", 0, "random seed for reproducible faults (0 is random)")
+	flag.Parse()
+
+	mock := mockupstream.New(dataset.Countries(), mockupstream.Faults{
+		Latency:       *latency,
+		Jitter:        *jitter,
+		ThrottleRate:  *throttleRate,
+		ErrorRate:     *errorRate,
+		MalformedRate: *malformedRate,
+		Seed:          *seed,
+	})
+
+	handler := mock.Handler()
+	if *fixtures != "" {
+		rep, err := httpfixture.NewReplayer(*fixtures)
+		if err != nil {
+			log.Fatalf("error loading fixtures: %v", err)
+		}
+		handler = mock.FixtureHandler(rep)
+		log.Printf("serving %d fixtures from %s", rep.Len(), *fixtures)
+	}
+
+	server := &http.Server{
+		Addr:         *addr,
+		Handler:      handler,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: time.Minute,
+	}
+	log.Printf("mock upstream listening on %s", *addr)
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatalf("http server error: %v", err)
+	}
+}
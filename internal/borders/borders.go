@@ -0,0 +1,116 @@
+// Package borders is a graph of the countries that share a land border,
+// for walking neighbourhoods and finding land routes.
+package borders
+
+import (
+	"CountrySearch/internal/externalapi"
+	"slices"
+)
+
+// Graph links countries by alpha-3 code. Borders are treated as mutual
+// even where the dataset lists only one side, and codes of countries not
+// in the dataset are dropped.
+type Graph struct {
+	adjacent map[string][]string // sorted, so walks are deterministic
+}
+
+func New(countries []externalapi.Country) *Graph {
+	known := make(map[string]bool, len(countries))
+	for _, c := range countries {
+		known[c.Alpha3Code] = true
+	}
+
+	g := &Graph{adjacent: make(map[string][]string, len(countries))}
+	for _, c := range countries {
+		for _, b := range c.Borders {
+			if known[b] && b != c.Alpha3Code {
+				g.link(c.Alpha3Code, b)
+				g.link(b, c.Alpha3Code)
+			}
+		}
+	}
+	for code := range g.adjacent {
+		slices.Sort(g.adjacent[code])
+	}
+	return g
+}
+
+func (g *Graph) link(a, b string) {
+	if !slices.Contains(g.adjacent[a], b) {
+		g.adjacent[a] = append(g.adjacent[a], b)
+	}
+}
+
+// Neighbour is a country reachable overland and the fewest border
+// crossings it takes.
+type Neighbour struct {
+	Code     string
+	Distance int
+}
+
+// Neighbours returns every country within depth crossings of code,
+// nearest first and by code within a distance. code itself is left out.
+func (g *Graph) Neighbours(code string, depth int) []Neighbour {
+	var found []Neighbour
+	g.walk(code, func(c, from string, distance int) bool {
+		if distance > depth {
+			return false
+		}
+		if distance > 0 {
+			found = append(found, Neighbour{Code: c, Distance: distance})
+		}
+		return true
+	})
+	return found
+}
+
+// Route returns the shortest land route from one country to another as
+// the codes along it, both ends included, or false if there is none.
+func (g *Graph) Route(from, to string) ([]string, bool) {
+	if from == to {
+		return []string{from}, true
+	}
+
+	via := make(map[string]string)
+	g.walk(from, func(c, prev string, distance int) bool {
+		via[c] = prev
+		return c != to
+	})
+	if _, ok := via[to]; !ok {
+		return nil, false
+	}
+
+	route := []string{to}
+	for c := to; c != from; {
+		c = via[c]
+		route = append(route, c)
+	}
+	slices.Reverse(route)
+	return route, true
+}
+
+// walk visits the countries reachable from start breadth first, calling
+// visit with each, the country it was reached from and its distance.
+// The walk stops when visit returns false.
+func (g *Graph) walk(start string, visit func(code, from string, distance int) bool) {
+	type step struct {
+		code, from string
+		distance   int
+	}
+
+	seen := map[string]bool{start: true}
+	queue := []step{{code: start}}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if !visit(s.code, s.from, s.distance) {
+			return
+		}
+		for _, next := range g.adjacent[s.code] {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, step{code: next, from: s.code, distance: s.distance + 1})
+			}
+		}
+	}
+}
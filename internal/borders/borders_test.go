@@ -0,0 +1,61 @@
+package borders
+
+import (
+	"CountrySearch/internal/externalapi"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A chain from Portugal to China, plus an island. France's border with
+// Spain is only listed on Spain's side.
+var testCountries = []externalapi.Country{
+	{Alpha3Code: "PRT", Borders: []string{"ESP"}},
+	{Alpha3Code: "ESP", Borders: []string{"PRT", "FRA", "AND"}},
+	{Alpha3Code: "AND", Borders: []string{"ESP", "FRA"}},
+	{Alpha3Code: "FRA", Borders: []string{"AND", "DEU", "XXX"}},
+	{Alpha3Code: "DEU", Borders: []string{"FRA", "POL"}},
+	{Alpha3Code: "POL", Borders: []string{"DEU", "RUS"}},
+	{Alpha3Code: "RUS", Borders: []string{"POL", "CHN"}},
+	{Alpha3Code: "CHN", Borders: []string{"RUS"}},
+	{Alpha3Code: "ISL"},
+}
+
+func TestGraph_Neighbours(t *testing.T) {
+	g := New(testCountries)
+
+	assert.Equal(t, []Neighbour{{Code: "ESP", Distance: 1}}, g.Neighbours("PRT", 1))
+	assert.Equal(t, []Neighbour{
+		{Code: "AND", Distance: 1},
+		{Code: "DEU", Distance: 1},
+		{Code: "ESP", Distance: 1},
+		{Code: "POL", Distance: 2},
+		{Code: "PRT", Distance: 2},
+	}, g.Neighbours("FRA", 2))
+	assert.Empty(t, g.Neighbours("ISL", 3))
+	assert.Empty(t, g.Neighbours("PRT", 0))
+}
+
+func TestGraph_BordersAreMutual(t *testing.T) {
+	g := New(testCountries)
+
+	assert.Contains(t, g.Neighbours("FRA", 1), Neighbour{Code: "ESP", Distance: 1})
+	assert.NotContains(t, g.Neighbours("FRA", 1), Neighbour{Code: "XXX", Distance: 1}, "unknown codes are dropped")
+}
+
+func TestGraph_Route(t *testing.T) {
+	g := New(testCountries)
+
+	route, ok := g.Route("PRT", "CHN")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"PRT", "ESP", "FRA", "DEU", "POL", "RUS", "CHN"}, route)
+
+	route, ok = g.Route("PRT", "PRT")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"PRT"}, route)
+
+	_, ok = g.Route("PRT", "ISL")
+	assert.False(t, ok)
+	_, ok = g.Route("ISL", "PRT")
+	assert.False(t, ok)
+}
@@ -0,0 +1,252 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by Allow while the breaker is rejecting calls.
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type Config struct {
+	Window         time.Duration // rolling window the failure rate is measured over
+	MinRequests    int           // requests needed in the window before the breaker can trip
+	FailureRate    float64       // failure ratio (0-1) that trips the breaker
+	CoolDown       time.Duration // time spent open before letting probes through
+	HalfOpenProbes int           // successful probes needed to close again
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Window:         30 * time.Second,
+		MinRequests:    10,
+		FailureRate:    0.5,
+		CoolDown:       15 * time.Second,
+		HalfOpenProbes: 1,
+	}
+}
+
+// Snapshot is the breaker's state and the request counts of its current
+// window, as reported on /health.
+type Snapshot struct {
+	State       string     `json:"state"`
+	Requests    int        `json:"requests"`
+	Failures    int        `json:"failures"`
+	FailureRate float64    `json:"failure_rate"`
+	OpenUntil   *time.Time `json:"open_until,omitempty"`
+}
+
+const numBuckets = 10
+
+// minWindow is the shortest window New accepts, so each bucket spans a
+// measurable slice of time.
+const minWindow = time.Second
+
+type bucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+type Breaker struct {
+	cfg Config
+	now func() time.Time
+
+	mu             sync.Mutex
+	state          State
+	openedAt       time.Time
+	buckets        [numBuckets]bucket
+	probes         int // half-open probes currently in flight
+	probeSuccesses int
+}
+
+func New(cfg Config) *Breaker {
+	def := DefaultConfig()
+	if cfg.Window <= 0 {
+		cfg.Window = def.Window
+	}
+	cfg.Window = max(cfg.Window, minWindow)
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = def.MinRequests
+	}
+	if cfg.FailureRate <= 0 || cfg.FailureRate > 1 {
+		cfg.FailureRate = def.FailureRate
+	}
+	if cfg.CoolDown <= 0 {
+		cfg.CoolDown = def.CoolDown
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = def.HalfOpenProbes
+	}
+
+	return &Breaker{
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed. Every nil return must be
+// followed by exactly one of Success, Failure or Release.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if b.now().Sub(b.openedAt) < b.cfg.CoolDown {
+			return ErrOpen
+		}
+		b.state = StateHalfOpen
+		b.probes = 0
+		b.probeSuccesses = 0
+	}
+
+	if b.state == StateHalfOpen {
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return ErrOpen
+		}
+		b.probes++
+	}
+
+	return nil
+}
+
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateHalfOpen:
+		b.probes--
+		b.probeSuccesses++
+		if b.probeSuccesses >= b.cfg.HalfOpenProbes {
+			b.reset()
+		}
+	case StateClosed:
+		b.current().requests++
+	}
+}
+
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateHalfOpen:
+		b.trip()
+	case StateClosed:
+		bk := b.current()
+		bk.requests++
+		bk.failures++
+
+		requests, failures := b.totals()
+		if requests >= b.cfg.MinRequests && float64(failures)/float64(requests) >= b.cfg.FailureRate {
+			b.trip()
+		}
+	}
+}
+
+// Release gives back a slot obtained from Allow without recording an
+// outcome, e.g. when the caller went away before the upstream answered.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cfg.CoolDown {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+func (b *Breaker) Snapshot() Snapshot {
+	state := b.State()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	requests, failures := b.totals()
+	snap := Snapshot{
+		State:    state.String(),
+		Requests: requests,
+		Failures: failures,
+	}
+	if requests > 0 {
+		snap.FailureRate = float64(failures) / float64(requests)
+	}
+	if state == StateOpen {
+		until := b.openedAt.Add(b.cfg.CoolDown)
+		snap.OpenUntil = &until
+	}
+	return snap
+}
+
+func (b *Breaker) trip() {
+	b.state = StateOpen
+	b.openedAt = b.now()
+	b.probes = 0
+	b.probeSuccesses = 0
+}
+
+func (b *Breaker) reset() {
+	b.state = StateClosed
+	b.probes = 0
+	b.probeSuccesses = 0
+	b.buckets = [numBuckets]bucket{}
+}
+
+func (b *Breaker) bucketWidth() time.Duration {
+	return b.cfg.Window / numBuckets
+}
+
+// current returns the bucket for now, clearing it if it last held an
+// older slice of time.
+func (b *Breaker) current() *bucket {
+	width := b.bucketWidth()
+	start := b.now().Truncate(width)
+	bk := &b.buckets[(start.UnixNano()/int64(width))%numBuckets]
+	if !bk.start.Equal(start) {
+		*bk = bucket{start: start}
+	}
+	return bk
+}
+
+func (b *Breaker) totals() (requests, failures int) {
+	cutoff := b.now().Add(-b.cfg.Window)
+	for _, bk := range b.buckets {
+		if bk.start.After(cutoff) {
+			requests += bk.requests
+			failures += bk.failures
+		}
+	}
+	return requests, failures
+}
@@ -0,0 +1,153 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.t }
+
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker() (*Breaker, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := New(Config{
+		Window:         10 * time.Second,
+		MinRequests:    4,
+		FailureRate:    0.5,
+		CoolDown:       5 * time.Second,
+		HalfOpenProbes: 1,
+	})
+	b.now = clock.Now
+	return b, clock
+}
+
+func record(b *Breaker, success bool) {
+	if err := b.Allow(); err != nil {
+		return
+	}
+	if success {
+		b.Success()
+	} else {
+		b.Failure()
+	}
+}
+
+func TestNew_AppliesDefaults(t *testing.T) {
+	b := New(Config{})
+	assert.Equal(t, DefaultConfig(), b.cfg)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestNew_ClampsTinyWindow(t *testing.T) {
+	b := New(Config{Window: 5 * time.Nanosecond})
+	assert.Equal(t, time.Second, b.cfg.Window)
+
+	assert.NotPanics(t, func() { record(b, false) })
+	assert.Equal(t, 1, b.Snapshot().Failures)
+}
+
+func TestBreaker_StaysClosedBelowMinRequests(t *testing.T) {
+	b, _ := newTestBreaker()
+
+	for i := 0; i < 3; i++ {
+		record(b, false)
+	}
+
+	assert.Equal(t, StateClosed, b.State())
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreaker_TripsOnFailureRate(t *testing.T) {
+	b, _ := newTestBreaker()
+
+	record(b, true)
+	record(b, true)
+	record(b, false)
+	record(b, false)
+
+	assert.Equal(t, StateOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+}
+
+func TestBreaker_WindowForgetsOldFailures(t *testing.T) {
+	b, clock := newTestBreaker()
+
+	record(b, false)
+	record(b, false)
+	record(b, false)
+	clock.Advance(11 * time.Second)
+	record(b, false)
+
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_HalfOpenAfterCoolDown(t *testing.T) {
+	b, clock := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		record(b, false)
+	}
+	require.Equal(t, StateOpen, b.State())
+
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	require.NoError(t, b.Allow())
+	assert.ErrorIs(t, b.Allow(), ErrOpen, "only one probe allowed at a time")
+
+	b.Success()
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	b, clock := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		record(b, false)
+	}
+	clock.Advance(5 * time.Second)
+
+	require.NoError(t, b.Allow())
+	b.Failure()
+
+	assert.Equal(t, StateOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+}
+
+func TestBreaker_ReleaseFreesProbeSlot(t *testing.T) {
+	b, clock := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		record(b, false)
+	}
+	clock.Advance(5 * time.Second)
+
+	require.NoError(t, b.Allow())
+	b.Release()
+
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreaker_Snapshot(t *testing.T) {
+	b, _ := newTestBreaker()
+	record(b, true)
+	record(b, false)
+
+	snap := b.Snapshot()
+	assert.Equal(t, "closed", snap.State)
+	assert.Equal(t, 2, snap.Requests)
+	assert.Equal(t, 1, snap.Failures)
+	assert.Equal(t, 0.5, snap.FailureRate)
+	assert.Nil(t, snap.OpenUntil)
+
+	record(b, false)
+	record(b, false)
+	snap = b.Snapshot()
+	assert.Equal(t, "open", snap.State)
+	assert.NotNil(t, snap.OpenUntil)
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"CountrySearch/internal/breaker"
+	"CountrySearch/internal/datasync"
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/hedge"
+	"CountrySearch/internal/httpclient"
+	"CountrySearch/internal/probe"
+	"CountrySearch/internal/ratelimit"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds the service settings, read from environment variables.
+type Config struct {
+	Port          int
+	CacheCapacity int
+	CacheTTL      time.Duration
+	// IndexTTL is how long the index over the provider's full list, used
+	// until a synced one is loaded, is fresh.
+	IndexTTL time.Duration
+
+	// BatchMaxItems caps the names or codes in one batch lookup, and
+	// BatchWorkers how many of them are looked up at once when the full
+	// list can't be fetched.
+	BatchMaxItems int
+	BatchWorkers  int
+
+	// EnrichMaxBytes caps the CSV uploaded to the enrich endpoint.
+	EnrichMaxBytes int
+
+	// Offline serves everything from the embedded dataset and never calls
+	// the upstream. OfflineFallback keeps the dataset behind the upstream.
+	Offline         bool
+	OfflineFallback bool
+
+	UpstreamBaseURL string
+	// UpstreamRecordDir saves every upstream exchange there as a fixture;
+	// UpstreamReplayDir answers upstream requests from such fixtures
+	// without touching the network.
+	UpstreamRecordDir string
+	UpstreamReplayDir string
+	// UpstreamMaxResponseSize bounds an upstream response body, in bytes.
+	UpstreamMaxResponseSize int64
+	// HTTPClient configures the client shared by every upstream provider.
+	HTTPClient httpclient.Config
+	Breaker    breaker.Config
+	RateLimit  ratelimit.Config
+	Sync       datasync.Config
+	Probe      probe.Config
+
+	// HedgeEnabled sends a backup search when the upstream is slower than
+	// usual. HedgeBackup names the provider it goes to: empty for the same
+	// upstream, or "restcountries".
+	HedgeEnabled         bool
+	HedgeBackup          string
+	Hedge                hedge.Config
+	RESTCountriesBaseURL string
+}
+
+func Load() Config {
+	def := breaker.DefaultConfig()
+	limits := ratelimit.DefaultConfig()
+	sync := datasync.DefaultConfig()
+	client := httpclient.DefaultConfig()
+	hedging := hedge.DefaultConfig()
+	probes := probe.DefaultConfig()
+
+	return Config{
+		Port:          envInt("PORT", 8080),
+		CacheCapacity: envInt("CACHE_CAPACITY", 100),
+		CacheTTL:      envDuration("CACHE_TTL", time.Hour),
+		IndexTTL:      envDuration("INDEX_TTL", 6*time.Hour),
+
+		BatchMaxItems: envInt("BATCH_MAX_ITEMS", 1000),
+		BatchWorkers:  envInt("BATCH_WORKERS", 8),
+
+		EnrichMaxBytes: envInt("ENRICH_MAX_BYTES", 32<<20),
+
+		Offline:         envBool("OFFLINE", false),
+		OfflineFallback: envBool("OFFLINE_FALLBACK", true),
+
+		UpstreamBaseURL:         envString("UPSTREAM_BASE_URL", ""),
+		UpstreamRecordDir:       envString("UPSTREAM_RECORD_DIR", ""),
+		UpstreamReplayDir:       envString("UPSTREAM_REPLAY_DIR", ""),
+		UpstreamMaxResponseSize: int64(envInt("UPSTREAM_MAX_RESPONSE_SIZE", externalapi.DefaultMaxResponseSize)),
+		HTTPClient: httpclient.Config{
+			Timeout:               envDuration("UPSTREAM_TIMEOUT", client.Timeout),
+			DialTimeout:           envDuration("UPSTREAM_DIAL_TIMEOUT", client.DialTimeout),
+			TLSHandshakeTimeout:   envDuration("UPSTREAM_TLS_HANDSHAKE_TIMEOUT", client.TLSHandshakeTimeout),
+			ResponseHeaderTimeout: envDuration("UPSTREAM_RESPONSE_HEADER_TIMEOUT", client.ResponseHeaderTimeout),
+			IdleConnTimeout:       envDuration("UPSTREAM_IDLE_CONN_TIMEOUT", client.IdleConnTimeout),
+			MaxIdleConns:          envInt("UPSTREAM_MAX_IDLE_CONNS", client.MaxIdleConns),
+			MaxIdleConnsPerHost:   envInt("UPSTREAM_MAX_IDLE_CONNS_PER_HOST", client.MaxIdleConnsPerHost),
+			MaxConnsPerHost:       envInt("UPSTREAM_MAX_CONNS_PER_HOST", client.MaxConnsPerHost),
+			Proxy:                 envString("UPSTREAM_PROXY", client.Proxy),
+			CABundle:              envString("UPSTREAM_CA_BUNDLE", client.CABundle),
+			UserAgent:             envString("UPSTREAM_USER_AGENT", client.UserAgent),
+		},
+		Breaker: breaker.Config{
+			Window:         envDuration("BREAKER_WINDOW", def.Window),
+			MinRequests:    envInt("BREAKER_MIN_REQUESTS", def.MinRequests),
+			FailureRate:    envFloat("BREAKER_FAILURE_RATE", def.FailureRate),
+			CoolDown:       envDuration("BREAKER_COOL_DOWN", def.CoolDown),
+			HalfOpenProbes: envInt("BREAKER_HALF_OPEN_PROBES", def.HalfOpenProbes),
+		},
+		RateLimit: ratelimit.Config{
+			Rate:       envFloat("UPSTREAM_RATE", limits.Rate),
+			Burst:      envInt("UPSTREAM_BURST", limits.Burst),
+			DailyQuota: envInt("UPSTREAM_DAILY_QUOTA", limits.DailyQuota),
+			QuotaFile:  envString("UPSTREAM_QUOTA_FILE", limits.QuotaFile),
+			Mode:       ratelimit.Mode(envString("UPSTREAM_LIMIT_MODE", string(limits.Mode))),
+			MaxWait:    envDuration("UPSTREAM_LIMIT_MAX_WAIT", limits.MaxWait),
+		},
+		Sync: datasync.Config{
+			Interval:     envDuration("SYNC_INTERVAL", sync.Interval),
+			Timeout:      envDuration("SYNC_TIMEOUT", sync.Timeout),
+			MinCountries: envInt("SYNC_MIN_COUNTRIES", sync.MinCountries),
+		},
+		Probe: probe.Config{
+			Interval:         envDuration("PROBE_INTERVAL", probes.Interval),
+			Timeout:          envDuration("PROBE_TIMEOUT", probes.Timeout),
+			Query:            envString("PROBE_QUERY", probes.Query),
+			History:          envInt("PROBE_HISTORY", probes.History),
+			FailureThreshold: envInt("PROBE_FAILURE_THRESHOLD", probes.FailureThreshold),
+		},
+
+		HedgeEnabled: envBool("HEDGE_ENABLED", false),
+		HedgeBackup:  envString("HEDGE_BACKUP", ""),
+		Hedge: hedge.Config{
+			Percentile: envFloat("HEDGE_PERCENTILE", hedging.Percentile),
+			MinDelay:   envDuration("HEDGE_MIN_DELAY", hedging.MinDelay),
+			MaxDelay:   envDuration("HEDGE_MAX_DELAY", hedging.MaxDelay),
+			MaxRate:    envFloat("HEDGE_MAX_RATE", hedging.MaxRate),
+			Window:     hedging.Window,
+			MinSamples: hedging.MinSamples,
+		},
+		RESTCountriesBaseURL: envString("RESTCOUNTRIES_BASE_URL", externalapi.DefaultRESTCountriesBaseURL),
+	}
+}
+
+func envString(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("INVALID: %s=%q is not an integer, using %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+func envBool(key string, def bool) bool {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("INVALID: %s=%q is not a boolean, using %t", key, v, def)
+		return def
+	}
+	return b
+}
+
+func envFloat(key string, def float64) float64 {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("INVALID: %s=%q is not a number, using %v", key, v, def)
+		return def
+	}
+	return f
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("INVALID: %s=%q is not a duration, using %s", key, v, def)
+		return def
+	}
+	return d
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"CountrySearch/internal/breaker"
+	"CountrySearch/internal/datasync"
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/hedge"
+	"CountrySearch/internal/httpclient"
+	"CountrySearch/internal/probe"
+	"CountrySearch/internal/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad_Defaults(t *testing.T) {
+	t.Setenv("PORT", "")
+	t.Setenv("CACHE_TTL", "")
+
+	cfg := Load()
+
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, 100, cfg.CacheCapacity)
+	assert.Equal(t, time.Hour, cfg.CacheTTL)
+	assert.Equal(t, 6*time.Hour, cfg.IndexTTL)
+	assert.Equal(t, 1000, cfg.BatchMaxItems)
+	assert.Equal(t, 8, cfg.BatchWorkers)
+	assert.Equal(t, 32<<20, cfg.EnrichMaxBytes)
+	assert.False(t, cfg.Offline)
+	assert.True(t, cfg.OfflineFallback)
+	assert.Equal(t, breaker.DefaultConfig(), cfg.Breaker)
+	assert.Equal(t, ratelimit.DefaultConfig(), cfg.RateLimit)
+	assert.Equal(t, datasync.DefaultConfig(), cfg.Sync)
+	assert.Equal(t, int64(externalapi.DefaultMaxResponseSize), cfg.UpstreamMaxResponseSize)
+	assert.Equal(t, httpclient.DefaultConfig(), cfg.HTTPClient)
+	assert.False(t, cfg.HedgeEnabled)
+	assert.Equal(t, hedge.DefaultConfig(), cfg.Hedge)
+	assert.Equal(t, probe.DefaultConfig(), cfg.Probe)
+	assert.Equal(t, externalapi.DefaultRESTCountriesBaseURL, cfg.RESTCountriesBaseURL)
+}
+
+func TestLoad_FromEnvironment(t *testing.T) {
+	t.Setenv("PORT", "3000")
+	t.Setenv("CACHE_TTL", "5m")
+	t.Setenv("INDEX_TTL", "30m")
+	t.Setenv("BATCH_MAX_ITEMS", "50")
+	t.Setenv("ENRICH_MAX_BYTES", "1048576")
+	t.Setenv("UPSTREAM_BASE_URL", "http://localhost:9090")
+	t.Setenv("OFFLINE", "true")
+	t.Setenv("OFFLINE_FALLBACK", "0")
+	t.Setenv("BREAKER_FAILURE_RATE", "0.25")
+	t.Setenv("BREAKER_COOL_DOWN", "1m")
+	t.Setenv("UPSTREAM_RATE", "2.5")
+	t.Setenv("UPSTREAM_DAILY_QUOTA", "1000")
+	t.Setenv("UPSTREAM_LIMIT_MODE", "stale")
+	t.Setenv("SYNC_INTERVAL", "0")
+	t.Setenv("UPSTREAM_MAX_RESPONSE_SIZE", "1048576")
+	t.Setenv("UPSTREAM_TIMEOUT", "3s")
+	t.Setenv("UPSTREAM_MAX_IDLE_CONNS_PER_HOST", "32")
+	t.Setenv("UPSTREAM_PROXY", "http://proxy.internal:3128")
+	t.Setenv("UPSTREAM_USER_AGENT", "countries-staging/1.0")
+	t.Setenv("SYNC_MIN_COUNTRIES", "150")
+	t.Setenv("HEDGE_ENABLED", "true")
+	t.Setenv("HEDGE_BACKUP", "restcountries")
+	t.Setenv("HEDGE_PERCENTILE", "0.9")
+	t.Setenv("HEDGE_MAX_DELAY", "400ms")
+	t.Setenv("PROBE_INTERVAL", "10s")
+	t.Setenv("PROBE_QUERY", "Japan")
+
+	cfg := Load()
+
+	assert.Equal(t, 3000, cfg.Port)
+	assert.Equal(t, 5*time.Minute, cfg.CacheTTL)
+	assert.Equal(t, 30*time.Minute, cfg.IndexTTL)
+	assert.Equal(t, 50, cfg.BatchMaxItems)
+	assert.Equal(t, 1<<20, cfg.EnrichMaxBytes)
+	assert.Equal(t, "http://localhost:9090", cfg.UpstreamBaseURL)
+	assert.True(t, cfg.Offline)
+	assert.False(t, cfg.OfflineFallback)
+	assert.Equal(t, 0.25, cfg.Breaker.FailureRate)
+	assert.Equal(t, time.Minute, cfg.Breaker.CoolDown)
+	assert.Equal(t, 2.5, cfg.RateLimit.Rate)
+	assert.Equal(t, 1000, cfg.RateLimit.DailyQuota)
+	assert.Equal(t, ratelimit.ModeStale, cfg.RateLimit.Mode)
+	assert.Equal(t, time.Duration(0), cfg.Sync.Interval)
+	assert.Equal(t, 150, cfg.Sync.MinCountries)
+	assert.Equal(t, int64(1<<20), cfg.UpstreamMaxResponseSize)
+	assert.Equal(t, 3*time.Second, cfg.HTTPClient.Timeout)
+	assert.Equal(t, 32, cfg.HTTPClient.MaxIdleConnsPerHost)
+	assert.Equal(t, "http://proxy.internal:3128", cfg.HTTPClient.Proxy)
+	assert.Equal(t, "countries-staging/1.0", cfg.HTTPClient.UserAgent)
+	assert.True(t, cfg.HedgeEnabled)
+	assert.Equal(t, "restcountries", cfg.HedgeBackup)
+	assert.Equal(t, 0.9, cfg.Hedge.Percentile)
+	assert.Equal(t, 400*time.Millisecond, cfg.Hedge.MaxDelay)
+	assert.Equal(t, 10*time.Second, cfg.Probe.Interval)
+	assert.Equal(t, "Japan", cfg.Probe.Query)
+}
+
+func TestLoad_InvalidValuesFallBack(t *testing.T) {
+	t.Setenv("CACHE_CAPACITY", "lots")
+	t.Setenv("CACHE_TTL", "forever")
+	t.Setenv("BREAKER_FAILURE_RATE", "half")
+	t.Setenv("OFFLINE", "sometimes")
+
+	cfg := Load()
+
+	assert.Equal(t, 100, cfg.CacheCapacity)
+	assert.Equal(t, time.Hour, cfg.CacheTTL)
+	assert.Equal(t, breaker.DefaultConfig().FailureRate, cfg.Breaker.FailureRate)
+	assert.False(t, cfg.Offline)
+}
@@ -0,0 +1,62 @@
+// Package dataset is a complete country dataset compiled into the binary,
+// for running fully offline or as the last fallback behind the upstream.
+// Regenerate countries.json with cmd/gendataset.
+package dataset
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/index"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+//go:generate go run ../../cmd/gendataset -out countries.json
+
+//go:embed countries.json
+var countriesJSON []byte
+
+var (
+	loadOnce  sync.Once
+	countries []externalapi.Country
+)
+
+// Countries returns the embedded dataset, sorted by name. Callers must not
+// modify the returned slice.
+func Countries() []externalapi.Country {
+	loadOnce.Do(func() {
+		if err := json.Unmarshal(countriesJSON, &countries); err != nil {
+			log.Fatalf("error parsing embedded country dataset. Err: %v", err)
+		}
+	})
+	return countries
+}
+
+// Provider serves countries from the embedded dataset.
+type Provider struct {
+	index *index.Index
+}
+
+func NewProvider() *Provider {
+	return &Provider{index: index.New(Countries())}
+}
+
+func (p *Provider) Name() string {
+	return "offline"
+}
+
+// SearchCountries matches name against every known name of each country,
+// as a substring, the same way the upstream's name search does.
+func (p *Provider) SearchCountries(ctx context.Context, name string) ([]externalapi.Country, error) {
+	found := p.index.Search(name)
+	if len(found) == 0 {
+		return nil, externalapi.ErrCountryNotFound
+	}
+	return found, nil
+}
+
+func (p *Provider) ListCountries(ctx context.Context) ([]externalapi.Country, error) {
+	return p.index.All(), nil
+}
@@ -0,0 +1,84 @@
+package dataset
+
+import (
+	"CountrySearch/internal/externalapi"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountries_IsComplete(t *testing.T) {
+	countries := Countries()
+
+	assert.GreaterOrEqual(t, len(countries), 249)
+
+	alpha2 := map[string]bool{}
+	alpha3 := map[string]bool{}
+	for _, c := range countries {
+		assert.NotEmpty(t, c.Name)
+		assert.Len(t, c.Alpha2Code, 2, c.Name)
+		assert.Len(t, c.Alpha3Code, 3, c.Name)
+		assert.Len(t, c.NumericCode, 3, c.Name)
+		assert.False(t, alpha2[c.Alpha2Code], "duplicate %s", c.Alpha2Code)
+		assert.False(t, alpha3[c.Alpha3Code], "duplicate %s", c.Alpha3Code)
+		alpha2[c.Alpha2Code] = true
+		alpha3[c.Alpha3Code] = true
+	}
+}
+
+func TestCountries_BordersAreKnownAndSymmetric(t *testing.T) {
+	borders := map[string][]string{}
+	for _, c := range Countries() {
+		borders[c.Alpha3Code] = c.Borders
+	}
+
+	for code, neighbours := range borders {
+		for _, n := range neighbours {
+			require.Contains(t, borders, n, "%s borders unknown %s", code, n)
+			assert.Contains(t, borders[n], code, "%s borders %s but not the reverse", code, n)
+		}
+	}
+}
+
+func TestProvider_SearchCountries(t *testing.T) {
+	p := NewProvider()
+
+	countries, err := p.SearchCountries(context.Background(), "guinea")
+
+	require.NoError(t, err)
+	var names []string
+	for _, c := range countries {
+		names = append(names, c.Name)
+	}
+	assert.ElementsMatch(t, []string{"Guinea", "Guinea-Bissau", "Equatorial Guinea", "Papua New Guinea"}, names)
+}
+
+func TestProvider_FetchCountry(t *testing.T) {
+	country, err := externalapi.FetchCountry(context.Background(), NewProvider(), "germany")
+
+	require.NoError(t, err)
+	assert.Equal(t, "DEU", country.Alpha3Code)
+	assert.Equal(t, "Berlin", country.Capital)
+	require.NotEmpty(t, country.Currencies)
+	assert.Equal(t, "EUR", country.Currencies[0].Code)
+}
+
+func TestProvider_MatchesAltSpellings(t *testing.T) {
+	countries, err := NewProvider().SearchCountries(context.Background(), "Russian Federation")
+
+	require.NoError(t, err)
+	require.Len(t, countries, 1)
+	assert.Equal(t, "Russia", countries[0].Name)
+}
+
+func TestProvider_NotFound(t *testing.T) {
+	p := NewProvider()
+
+	_, err := p.SearchCountries(context.Background(), "Atlantis")
+	assert.ErrorIs(t, err, externalapi.ErrCountryNotFound)
+
+	_, err = p.SearchCountries(context.Background(), " ")
+	assert.ErrorIs(t, err, externalapi.ErrCountryNotFound)
+}
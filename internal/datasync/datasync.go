@@ -0,0 +1,129 @@
+// Package datasync periodically downloads the provider's entire dataset
+// and keeps a local index of it, so lookups need no upstream call.
+package datasync
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/index"
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Config struct {
+	Interval     time.Duration // time between syncs, 0 disables syncing
+	Timeout      time.Duration // limit on a single download
+	MinCountries int           // smallest dataset accepted as complete
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Interval:     6 * time.Hour,
+		Timeout:      time.Minute,
+		MinCountries: 200,
+	}
+}
+
+// Status says whether a dataset is loaded, how big it is and when the
+// last sync ran or why it failed.
+type Status struct {
+	Loaded    bool       `json:"loaded"`
+	Countries int        `json:"countries"`
+	LastSync  *time.Time `json:"last_sync,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+type Syncer struct {
+	provider externalapi.CountryProvider
+	cfg      Config
+
+	index atomic.Pointer[index.Index]
+
+	mu       sync.Mutex
+	lastSync time.Time
+	lastErr  error
+}
+
+func New(provider externalapi.CountryProvider, cfg Config) *Syncer {
+	def := DefaultConfig()
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = def.Timeout
+	}
+	if cfg.MinCountries <= 0 {
+		cfg.MinCountries = def.MinCountries
+	}
+
+	return &Syncer{
+		provider: provider,
+		cfg:      cfg,
+	}
+}
+
+// Run syncs straight away and then every interval until ctx is done.
+func (s *Syncer) Run(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("error syncing country dataset: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync downloads and validates the dataset, then swaps it in. On failure
+// the previous index stays in place.
+func (s *Syncer) Sync(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	countries, err := s.provider.ListCountries(ctx)
+	if err == nil {
+		err = index.Validate(countries, s.cfg.MinCountries)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+	if err != nil {
+		return err
+	}
+
+	s.index.Store(index.New(countries))
+	s.lastSync = time.Now()
+	log.Printf("synced %d countries from %s", len(countries), s.provider.Name())
+	return nil
+}
+
+// Index returns the current index, or nil before the first successful sync.
+func (s *Syncer) Index() *index.Index {
+	return s.index.Load()
+}
+
+func (s *Syncer) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var status Status
+	if idx := s.index.Load(); idx != nil {
+		status.Loaded = true
+		status.Countries = idx.Len()
+		last := s.lastSync
+		status.LastSync = &last
+	}
+	if s.lastErr != nil {
+		status.LastError = s.lastErr.Error()
+	}
+	return status
+}
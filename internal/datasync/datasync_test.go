@@ -0,0 +1,110 @@
+package datasync
+
+import (
+	"CountrySearch/internal/externalapi"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type listProvider struct {
+	countries []externalapi.Country
+	err       error
+	calls     atomic.Int32
+}
+
+func (p *listProvider) Name() string { return "list" }
+
+func (p *listProvider) SearchCountries(ctx context.Context, name string) ([]externalapi.Country, error) {
+	return nil, errors.New("not used")
+}
+
+func (p *listProvider) ListCountries(ctx context.Context) ([]externalapi.Country, error) {
+	p.calls.Add(1)
+	return p.countries, p.err
+}
+
+func fakeCountries(n int) []externalapi.Country {
+	countries := make([]externalapi.Country, n)
+	for i := range countries {
+		countries[i] = externalapi.Country{
+			Name:       fmt.Sprintf("Country %d", i),
+			Alpha3Code: fmt.Sprintf("C%02d", i),
+		}
+	}
+	return countries
+}
+
+func TestSyncer_SyncLoadsIndex(t *testing.T) {
+	s := New(&listProvider{countries: fakeCountries(3)}, Config{MinCountries: 3})
+	assert.Nil(t, s.Index())
+
+	require.NoError(t, s.Sync(context.Background()))
+
+	require.NotNil(t, s.Index())
+	c, ok := s.Index().ByCode("C01")
+	require.True(t, ok)
+	assert.Equal(t, "Country 1", c.Name)
+
+	status := s.Status()
+	assert.True(t, status.Loaded)
+	assert.Equal(t, 3, status.Countries)
+	assert.NotNil(t, status.LastSync)
+	assert.Empty(t, status.LastError)
+}
+
+func TestSyncer_RejectsIncompleteDataset(t *testing.T) {
+	p := &listProvider{countries: fakeCountries(5)}
+	s := New(p, Config{MinCountries: 5})
+	require.NoError(t, s.Sync(context.Background()))
+
+	p.countries = fakeCountries(2)
+	err := s.Sync(context.Background())
+
+	assert.ErrorContains(t, err, "at least 5")
+	assert.Equal(t, 5, s.Index().Len(), "previous index is kept")
+	assert.Contains(t, s.Status().LastError, "at least 5")
+}
+
+func TestSyncer_KeepsIndexWhenProviderFails(t *testing.T) {
+	p := &listProvider{countries: fakeCountries(2)}
+	s := New(p, Config{MinCountries: 1})
+	require.NoError(t, s.Sync(context.Background()))
+
+	p.err = errors.New("upstream down")
+	assert.Error(t, s.Sync(context.Background()))
+
+	assert.Equal(t, 2, s.Index().Len())
+}
+
+func TestSyncer_RunSyncsPeriodically(t *testing.T) {
+	p := &listProvider{countries: fakeCountries(1)}
+	s := New(p, Config{Interval: 10 * time.Millisecond, MinCountries: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return p.calls.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+	assert.NotNil(t, s.Index())
+}
+
+func TestSyncer_ZeroIntervalDisablesRun(t *testing.T) {
+	p := &listProvider{countries: fakeCountries(1)}
+	s := New(p, Config{MinCountries: 1})
+
+	s.Run(context.Background())
+
+	assert.Equal(t, int32(0), p.calls.Load())
+}
@@ -0,0 +1,69 @@
+package externalapi
+
+import (
+	"CountrySearch/internal/breaker"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// BreakerProvider guards a CountryProvider with a circuit breaker so that
+// calls fail fast with ErrUnavailable while the upstream is unhealthy.
+type BreakerProvider struct {
+	next    CountryProvider
+	breaker *breaker.Breaker
+}
+
+func WithBreaker(next CountryProvider, b *breaker.Breaker) *BreakerProvider {
+	return &BreakerProvider{
+		next:    next,
+		breaker: b,
+	}
+}
+
+func (p *BreakerProvider) Name() string {
+	return p.next.Name()
+}
+
+func (p *BreakerProvider) Breaker() *breaker.Breaker {
+	return p.breaker
+}
+
+func (p *BreakerProvider) SearchCountries(ctx context.Context, name string) ([]Country, error) {
+	var countries []Country
+	err := p.do(ctx, func(ctx context.Context) error {
+		var err error
+		countries, err = p.next.SearchCountries(ctx, name)
+		return err
+	})
+	return countries, err
+}
+
+func (p *BreakerProvider) ListCountries(ctx context.Context) ([]Country, error) {
+	var countries []Country
+	err := p.do(ctx, func(ctx context.Context) error {
+		var err error
+		countries, err = p.next.ListCountries(ctx)
+		return err
+	})
+	return countries, err
+}
+
+func (p *BreakerProvider) do(ctx context.Context, call func(context.Context) error) error {
+	if err := p.breaker.Allow(); err != nil {
+		return fmt.Errorf("%s: %w: %w", p.Name(), ErrUnavailable, err)
+	}
+
+	err := call(ctx)
+	switch {
+	case err == nil, errors.Is(err, ErrCountryNotFound):
+		p.breaker.Success()
+	case ctx.Err() != nil, errors.Is(err, ErrUnavailable):
+		// Either the caller gave up or an inner guard never asked the
+		// upstream; neither says anything about its health.
+		p.breaker.Release()
+	default:
+		p.breaker.Failure()
+	}
+	return err
+}
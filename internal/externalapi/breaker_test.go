@@ -0,0 +1,105 @@
+package externalapi
+
+import (
+	"CountrySearch/internal/breaker"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProvider is a CountryProvider whose answers are scripted by the test.
+type stubProvider struct {
+	name   string
+	calls  int
+	search func(ctx context.Context, name string) ([]Country, error)
+}
+
+func (p *stubProvider) Name() string {
+	if p.name == "" {
+		return "stub"
+	}
+	return p.name
+}
+
+func (p *stubProvider) SearchCountries(ctx context.Context, name string) ([]Country, error) {
+	p.calls++
+	return p.search(ctx, name)
+}
+
+func (p *stubProvider) ListCountries(ctx context.Context) ([]Country, error) {
+	p.calls++
+	return p.search(ctx, "")
+}
+
+func tightBreaker() *breaker.Breaker {
+	return breaker.New(breaker.Config{
+		Window:      time.Minute,
+		MinRequests: 2,
+		FailureRate: 0.5,
+		CoolDown:    time.Minute,
+	})
+}
+
+func TestBreakerProvider_FailsFastWhenOpen(t *testing.T) {
+	stub := &stubProvider{search: func(ctx context.Context, name string) ([]Country, error) {
+		return nil, errors.New("timeout")
+	}}
+	p := WithBreaker(stub, tightBreaker())
+
+	for i := 0; i < 2; i++ {
+		_, err := p.SearchCountries(context.Background(), "France")
+		require.Error(t, err)
+	}
+	require.Equal(t, breaker.StateOpen, p.Breaker().State())
+
+	_, err := p.SearchCountries(context.Background(), "France")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.Equal(t, 2, stub.calls, "upstream must not be called while open")
+}
+
+func TestBreakerProvider_NotFoundIsNotAFailure(t *testing.T) {
+	stub := &stubProvider{search: func(ctx context.Context, name string) ([]Country, error) {
+		return nil, ErrCountryNotFound
+	}}
+	p := WithBreaker(stub, tightBreaker())
+
+	for i := 0; i < 5; i++ {
+		_, err := p.SearchCountries(context.Background(), "Atlantis")
+		assert.ErrorIs(t, err, ErrCountryNotFound)
+	}
+
+	assert.Equal(t, breaker.StateClosed, p.Breaker().State())
+}
+
+func TestBreakerProvider_CanceledCallerIsNotAFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stub := &stubProvider{search: func(ctx context.Context, name string) ([]Country, error) {
+		return nil, ctx.Err()
+	}}
+	p := WithBreaker(stub, tightBreaker())
+
+	for i := 0; i < 5; i++ {
+		_, _ = p.SearchCountries(ctx, "France")
+	}
+
+	assert.Equal(t, breaker.StateClosed, p.Breaker().State())
+}
+
+func TestBreakerProvider_PassesThroughSuccess(t *testing.T) {
+	stub := &stubProvider{name: "upstream", search: func(ctx context.Context, name string) ([]Country, error) {
+		return []Country{{Name: name}}, nil
+	}}
+	p := WithBreaker(stub, tightBreaker())
+
+	result, err := p.SearchCountries(context.Background(), "Peru")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Country{{Name: "Peru"}}, result)
+	assert.Equal(t, "upstream", p.Name())
+}
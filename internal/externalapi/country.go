@@ -0,0 +1,141 @@
+package externalapi
+
+import "CountrySearch/internal/iso4217"
+
+// Country is the full record for a country and the v2 public response.
+type Country struct {
+	Name         string   `json:"name"`
+	NativeName   string   `json:"native_name,omitempty"`
+	AltSpellings []string `json:"alt_spellings,omitempty"`
+
+	Alpha2Code  string `json:"alpha2_code"`
+	Alpha3Code  string `json:"alpha3_code"`
+	NumericCode string `json:"numeric_code"`
+
+	Capital    string  `json:"capital"`
+	Region     string  `json:"region"`
+	Subregion  string  `json:"subregion"`
+	Population int     `json:"population"`
+	Area       float64 `json:"area"`
+	// LatLng is the country's approximate centre as [latitude, longitude].
+	LatLng []float64 `json:"latlng,omitempty"`
+
+	Timezones       []string   `json:"timezones,omitempty"`
+	CallingCodes    []string   `json:"calling_codes,omitempty"`
+	TopLevelDomains []string   `json:"tlds,omitempty"`
+	Demonym         string     `json:"demonym,omitempty"`
+	Borders         []string   `json:"borders,omitempty"`
+	Languages       []Language `json:"languages,omitempty"`
+	Currencies      []Currency `json:"currencies,omitempty"`
+	Flags           Flags      `json:"flags"`
+}
+
+type Language struct {
+	ISO639_1   string `json:"iso639_1,omitempty"`
+	ISO639_2   string `json:"iso639_2,omitempty"`
+	Name       string `json:"name"`
+	NativeName string `json:"native_name,omitempty"`
+}
+
+type Currency struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+	// MinorUnits is the number of decimal places, when ISO 4217 defines one.
+	MinorUnits *int `json:"minor_units,omitempty"`
+}
+
+type Flags struct {
+	SVG string `json:"svg,omitempty"`
+	PNG string `json:"png,omitempty"`
+}
+
+// Names returns every name the country is known by, for matching.
+func (c Country) Names() []string {
+	names := []string{c.Name}
+	if c.NativeName != "" {
+		names = append(names, c.NativeName)
+	}
+	return append(names, c.AltSpellings...)
+}
+
+// SearchResponse projects the country onto the v1 response shape.
+func (c Country) SearchResponse() CountrySearchResponse {
+	resp := CountrySearchResponse{
+		Name:       c.Name,
+		Capital:    c.Capital,
+		Population: c.Population,
+	}
+	if len(c.Currencies) > 0 {
+		resp.Currency = c.Currencies[0].Symbol
+	}
+	return resp
+}
+
+// Country converts the upstream record to the public model.
+func (r CountryAPIResponse) Country() Country {
+	country := Country{
+		Name:            r.Name,
+		NativeName:      r.NativeName,
+		AltSpellings:    r.AltSpellings,
+		Alpha2Code:      r.Alpha2Code,
+		Alpha3Code:      r.Alpha3Code,
+		NumericCode:     r.NumericCode,
+		Capital:         r.Capital,
+		Region:          r.Region,
+		Subregion:       r.Subregion,
+		Population:      r.Population,
+		Area:            r.Area,
+		LatLng:          r.LatLng,
+		Timezones:       r.Timezones,
+		CallingCodes:    r.CallingCodes,
+		TopLevelDomains: r.TopLevelDomain,
+		Demonym:         r.Demonym,
+		Borders:         r.Borders,
+		Flags: Flags{
+			SVG: r.Flags.SVG,
+			PNG: r.Flags.PNG,
+		},
+	}
+	if country.Flags.SVG == "" {
+		country.Flags.SVG = r.Flag
+	}
+	for _, l := range r.Languages {
+		country.Languages = append(country.Languages, Language{
+			ISO639_1:   l.ISO639_1,
+			ISO639_2:   l.ISO639_2,
+			Name:       l.Name,
+			NativeName: l.NativeName,
+		})
+	}
+	for _, c := range r.Currencies {
+		country.Currencies = append(country.Currencies, completeCurrency(Currency{
+			Code:   c.Code,
+			Name:   c.Name,
+			Symbol: c.Symbol,
+		}))
+	}
+	return country
+}
+
+// completeCurrency fills in whatever the upstream left out from the
+// ISO 4217 table. Upstream values win where both are present.
+func completeCurrency(c Currency) Currency {
+	iso, ok := iso4217.Lookup(c.Code)
+	if !ok {
+		return c
+	}
+
+	c.Code = iso.Code
+	if c.Name == "" {
+		c.Name = iso.Name
+	}
+	if c.Symbol == "" {
+		c.Symbol = iso.Symbol
+	}
+	if c.MinorUnits == nil && iso.MinorUnits >= 0 {
+		minor := iso.MinorUnits
+		c.MinorUnits = &minor
+	}
+	return c
+}
@@ -0,0 +1,132 @@
+package externalapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const colombiaJSON = `[{
+	"name": "Colombia",
+	"topLevelDomain": [".co"],
+	"alpha2Code": "CO",
+	"alpha3Code": "COL",
+	"callingCodes": ["57"],
+	"capital": "Bogotá",
+	"altSpellings": ["CO", "Republic of Colombia", "República de Colombia"],
+	"subregion": "South America",
+	"region": "Americas",
+	"population": 50882884,
+	"latlng": [4.0, -72.0],
+	"demonym": "Colombian",
+	"area": 1141748.0,
+	"timezones": ["UTC-05:00"],
+	"borders": ["BRA", "ECU", "PAN", "PER", "VEN"],
+	"nativeName": "Colombia",
+	"numericCode": "170",
+	"flags": {"svg": "https://flagcdn.com/co.svg", "png": "https://flagcdn.com/w320/co.png"},
+	"currencies": [{"code": "COP", "name": "Colombian peso", "symbol": "$"}],
+	"languages": [{"iso639_1": "es", "iso639_2": "spa", "name": "Spanish", "nativeName": "Español"}],
+	"flag": "https://flagcdn.com/co.svg",
+	"independent": true
+}]`
+
+func TestAPICountriesProvider_DecodesFullRecord(t *testing.T) {
+	p := NewAPICountriesProvider("", mockClient(200, colombiaJSON))
+
+	country, err := FetchCountry(context.Background(), p, "colombia")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Colombia", country.Name)
+	assert.Equal(t, "CO", country.Alpha2Code)
+	assert.Equal(t, "COL", country.Alpha3Code)
+	assert.Equal(t, "170", country.NumericCode)
+	assert.Equal(t, "Americas", country.Region)
+	assert.Equal(t, "South America", country.Subregion)
+	assert.Equal(t, 1141748.0, country.Area)
+	assert.Equal(t, []float64{4, -72}, country.LatLng)
+	assert.Equal(t, []string{"UTC-05:00"}, country.Timezones)
+	assert.Equal(t, []string{"57"}, country.CallingCodes)
+	assert.Equal(t, []string{".co"}, country.TopLevelDomains)
+	assert.Equal(t, "Colombian", country.Demonym)
+	assert.Equal(t, []string{"BRA", "ECU", "PAN", "PER", "VEN"}, country.Borders)
+	assert.Equal(t, "https://flagcdn.com/w320/co.png", country.Flags.PNG)
+	require.Len(t, country.Languages, 1)
+	assert.Equal(t, Language{ISO639_1: "es", ISO639_2: "spa", Name: "Spanish", NativeName: "Español"}, country.Languages[0])
+	require.Len(t, country.Currencies, 1)
+	assert.Equal(t, "COP", country.Currencies[0].Code)
+	assert.Equal(t, "Colombian peso", country.Currencies[0].Name)
+	assert.Equal(t, "$", country.Currencies[0].Symbol)
+	require.NotNil(t, country.Currencies[0].MinorUnits)
+	assert.Equal(t, 2, *country.Currencies[0].MinorUnits)
+}
+
+func TestCountryAPIResponse_Country_FallsBackToFlagField(t *testing.T) {
+	r := CountryAPIResponse{Name: "Chad", Flag: "https://flagcdn.com/td.svg"}
+
+	assert.Equal(t, "https://flagcdn.com/td.svg", r.Country().Flags.SVG)
+}
+
+func TestCountry_SearchResponseKeepsV1Shape(t *testing.T) {
+	country := Country{
+		Name:       "Colombia",
+		Capital:    "Bogotá",
+		Currencies: []Currency{{Code: "COP", Symbol: "$"}, {Code: "USD", Symbol: "$"}},
+		Population: 50882884,
+		Alpha2Code: "CO",
+	}
+
+	body, err := json.Marshal(country.SearchResponse())
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Colombia","capital":"Bogotá","currency":"$","population":50882884}`, string(body))
+}
+
+func TestCountryAPIResponse_Country_KeepsEveryCurrency(t *testing.T) {
+	p := NewAPICountriesProvider("", mockClient(200, `[{
+		"name": "Zimbabwe",
+		"currencies": [
+			{"code": "ZWG", "name": "Zimbabwe Gold", "symbol": "ZiG"},
+			{"code": "USD", "name": "United States dollar", "symbol": "$"},
+			{"code": "ZAR"}
+		]
+	}]`))
+
+	country, err := FetchCountry(context.Background(), p, "Zimbabwe")
+
+	require.NoError(t, err)
+	require.Len(t, country.Currencies, 3)
+	assert.Equal(t, []string{"ZWG", "USD", "ZAR"}, []string{
+		country.Currencies[0].Code, country.Currencies[1].Code, country.Currencies[2].Code,
+	})
+	assert.Equal(t, "United States dollar", country.Currencies[1].Name, "upstream name wins")
+	assert.Equal(t, "Rand", country.Currencies[2].Name, "missing name filled from ISO 4217")
+	assert.Equal(t, "R", country.Currencies[2].Symbol)
+}
+
+func TestCompleteCurrency(t *testing.T) {
+	t.Run("fills minor units", func(t *testing.T) {
+		c := completeCurrency(Currency{Code: "bhd", Symbol: "BD"})
+
+		assert.Equal(t, "BHD", c.Code)
+		assert.Equal(t, "Bahraini Dinar", c.Name)
+		assert.Equal(t, "BD", c.Symbol)
+		require.NotNil(t, c.MinorUnits)
+		assert.Equal(t, 3, *c.MinorUnits)
+	})
+
+	t.Run("unknown code left alone", func(t *testing.T) {
+		c := completeCurrency(Currency{Code: "XYZ", Name: "Made up"})
+
+		assert.Equal(t, Currency{Code: "XYZ", Name: "Made up"}, c)
+	})
+
+	t.Run("precious metals have no minor units", func(t *testing.T) {
+		c := completeCurrency(Currency{Code: "XAU"})
+
+		assert.Nil(t, c.MinorUnits)
+	})
+}
@@ -1,13 +1,38 @@
 package externalapi
 
 import (
-	"encoding/json"
+	"CountrySearch/internal/httpclient"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 )
 
+// DefaultBaseURL is the apicountries.com endpoint used by FetchCountryData.
+const DefaultBaseURL = "https://www.apicountries.com"
+
+// DefaultMaxResponseSize bounds an upstream response body. The full
+// dataset is well under a megabyte.
+const DefaultMaxResponseSize = 8 << 20
+
+var (
+	// ErrResponseTooLarge means an upstream body went past the size limit.
+	ErrResponseTooLarge = errors.New("upstream response too large")
+	// ErrUpstreamFailed means the upstream couldn't be reached or answered
+	// with a status other than 200, 304, 404 or 429.
+	ErrUpstreamFailed = errors.New("upstream request failed")
+	// ErrUpstreamThrottled means the upstream answered 429 Too Many
+	// Requests.
+	ErrUpstreamThrottled = errors.New("upstream throttled the request")
+)
+
+// CountrySearchResponse is the v1 public response. Its shape is frozen;
+// new fields go on Country, which is served as v2.
 type CountrySearchResponse struct {
 	Name       string `json:"name"`
 	Capital    string `json:"capital"`
@@ -15,60 +40,178 @@ type CountrySearchResponse struct {
 	Population int    `json:"population"`
 }
 
+// CountryAPIResponse is a country as returned by apicountries.com.
 type CountryAPIResponse struct {
-	Name       string `json:"name"`
-	Capital    string `json:"capital"`
-	Population int    `json:"population"`
+	Name           string    `json:"name"`
+	NativeName     string    `json:"nativeName"`
+	AltSpellings   []string  `json:"altSpellings"`
+	Alpha2Code     string    `json:"alpha2Code"`
+	Alpha3Code     string    `json:"alpha3Code"`
+	NumericCode    string    `json:"numericCode"`
+	Capital        string    `json:"capital"`
+	Region         string    `json:"region"`
+	Subregion      string    `json:"subregion"`
+	Population     int       `json:"population"`
+	Area           float64   `json:"area"`
+	LatLng         []float64 `json:"latlng"`
+	Timezones      []string  `json:"timezones"`
+	CallingCodes   []string  `json:"callingCodes"`
+	TopLevelDomain []string  `json:"topLevelDomain"`
+	Demonym        string    `json:"demonym"`
+	Borders        []string  `json:"borders"`
+	Flag           string    `json:"flag"`
+	Flags          struct {
+		SVG string `json:"svg"`
+		PNG string `json:"png"`
+	} `json:"flags"`
+	Languages []struct {
+		ISO639_1   string `json:"iso639_1"`
+		ISO639_2   string `json:"iso639_2"`
+		Name       string `json:"name"`
+		NativeName string `json:"nativeName"`
+	} `json:"languages"`
 	Currencies []struct {
+		Code   string `json:"code"`
+		Name   string `json:"name"`
 		Symbol string `json:"symbol"`
 	} `json:"currencies"`
 }
 
 // FetchCountryDataWithClient allows dependency injection for testing
 func FetchCountryDataWithClient(name string, client *http.Client) (CountrySearchResponse, error) {
+	countries, err := searchCountries(context.Background(), client, DefaultBaseURL, name, fetchOptions{
+		limit: DefaultMaxResponseSize,
+		stop:  func(c Country) bool { return strings.EqualFold(c.Name, name) },
+	})
+	if err != nil {
+		return CountrySearchResponse{}, err
+	}
+
+	if country, err := FindCountry(countries, name); err == nil {
+		return country.SearchResponse(), nil
+	}
+	return CountrySearchResponse{}, nil
+}
+
+// FetchCountryData uses the shared default upstream client
+func FetchCountryData(name string) (CountrySearchResponse, error) {
+	return FetchCountryDataWithClient(name, httpclient.Default())
+}
+
+// fetchOptions tune how getCountries requests and reads a response.
+type fetchOptions struct {
+	limit int64
+	// stop ends decoding early once it accepts a country.
+	stop func(Country) bool
+	// validators, when set, makes requests conditional on the last
+	// response seen for the same URL.
+	validators *validatorCache
+}
+
+// searchCountries returns the countries apicountries.com matches for name.
+func searchCountries(ctx context.Context, client *http.Client, baseURL, name string, opts fetchOptions) ([]Country, error) {
 	if name == "" {
-		return CountrySearchResponse{}, fmt.Errorf("country name cannot be empty")
+		return nil, fmt.Errorf("country name cannot be empty")
+	}
+	return getCountries(ctx, client, baseURL+"/name/"+url.PathEscape(name), apiCountriesSchema, opts)
+}
+
+// listCountries returns apicountries.com's entire dataset.
+func listCountries(ctx context.Context, client *http.Client, baseURL string, opts fetchOptions) ([]Country, error) {
+	return getCountries(ctx, client, baseURL+"/countries", apiCountriesSchema, opts)
+}
+
+// getCountries streams the array of schema's records at url, reading at
+// most opts.limit bytes of it. What the upstream said about caching the
+// response is reported through the context's CacheInfo, if any.
+func getCountries[T any](ctx context.Context, client *http.Client, url string, schema *recordSchema[T], opts fetchOptions) ([]Country, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// A response read only up to one match can stand in for the same
+	// search, but not for one that needs every record.
+	previous, conditional := opts.validators.get(url)
+	conditional = conditional && (!previous.partial || opts.stop != nil)
+	if conditional {
+		previous.apply(req)
 	}
 
-	resp, err := client.Get("https://www.apicountries.com/name/" + name)
+	resp, err := client.Do(req)
 	if err != nil {
-		return CountrySearchResponse{}, err
+		return nil, fmt.Errorf("%w: failed to fetch country data: %w", ErrUpstreamFailed, err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return CountrySearchResponse{}, err
+	info := cacheInfoFrom(ctx)
+	info.record(resp.Header)
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		if info != nil {
+			info.NotModified = true
+		}
+		return slices.Clone(previous.countries), nil
 	}
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: api returned status %d", ErrCountryNotFound, resp.StatusCode)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("%w: api returned status %d", ErrUpstreamThrottled, resp.StatusCode)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return CountrySearchResponse{}, fmt.Errorf("api returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("%w: api returned status %d", ErrUpstreamFailed, resp.StatusCode)
+	}
+	if err := checkContentType(resp.Header.Get("Content-Type")); err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > opts.limit {
+		return nil, fmt.Errorf("%w: %d bytes, limit is %d", ErrResponseTooLarge, resp.ContentLength, opts.limit)
 	}
 
-	var apiResponse []CountryAPIResponse
-	err = json.Unmarshal(body, &apiResponse)
+	stopped := false
+	stop := opts.stop
+	if stop != nil {
+		stop = func(c Country) bool {
+			stopped = opts.stop(c)
+			return stopped
+		}
+	}
+	countries, err := decodeCountries(&limitedReader{r: resp.Body, n: opts.limit}, schema, stop)
 	if err != nil {
-		return CountrySearchResponse{}, err
+		return nil, err
 	}
+	opts.validators.put(url, resp.Header, countries, stopped)
+	return countries, nil
+}
 
-	for _, country := range apiResponse {
-		if strings.EqualFold(country.Name, name) {
-			response := CountrySearchResponse{
-				Name:       country.Name,
-				Capital:    country.Capital,
-				Population: country.Population,
-			}
-			if len(country.Currencies) > 0 {
-				response.Currency = country.Currencies[0].Symbol
-			}
-			return response, nil
-		}
+// checkContentType accepts JSON bodies, and bodies with no declared type
+// since some proxies strip it.
+func checkContentType(contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return fmt.Errorf("%w: unexpected content type %q", ErrInvalidResponse, contentType)
 	}
+	return nil
+}
 
-	return CountrySearchResponse{}, nil
+// limitedReader is io.LimitReader, but fails instead of ending quietly so
+// a truncated body is never mistaken for a complete one.
+type limitedReader struct {
+	r io.Reader
+	n int64
 }
 
-// FetchCountryData uses default HTTP client
-func FetchCountryData(name string) (CountrySearchResponse, error) {
-	return FetchCountryDataWithClient(name, http.DefaultClient)
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
 }
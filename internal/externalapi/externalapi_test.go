@@ -1,6 +1,7 @@
 package externalapi
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -241,3 +242,82 @@ func TestFetchCountryDataWithClient_NetworkError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to fetch country data")
 }
+
+func TestGetCountries_RejectsOversizedBody(t *testing.T) {
+	body := `[{"name": "France", "capital": "Paris"}, {"name": "Spain", "capital": "Madrid"}]`
+	client := mockClient(200, body)
+
+	_, err := getCountries(context.Background(), client, "http://upstream.test/countries", apiCountriesSchema, fetchOptions{limit: 20})
+
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+}
+
+func TestGetCountries_RejectsDeclaredOversizedBody(t *testing.T) {
+	client := &http.Client{
+		Transport: &MockRoundTripper{
+			RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode:    200,
+					ContentLength: 1 << 30,
+					Body:          io.NopCloser(strings.NewReader("[]")),
+				}, nil
+			},
+		},
+	}
+
+	_, err := getCountries(context.Background(), client, "http://upstream.test/countries", apiCountriesSchema, fetchOptions{limit: DefaultMaxResponseSize})
+
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+}
+
+func TestGetCountries_ChecksContentType(t *testing.T) {
+	for contentType, ok := range map[string]bool{
+		"":                                true,
+		"application/json":                true,
+		"application/json; charset=utf-8": true,
+		"application/problem+json":        true,
+		"text/html; charset=utf-8":        false,
+		"text/plain":                      false,
+	} {
+		client := &http.Client{
+			Transport: &MockRoundTripper{
+				RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: 200,
+						Header:     http.Header{"Content-Type": []string{contentType}},
+						Body:       io.NopCloser(strings.NewReader(`[{"name": "France"}]`)),
+					}, nil
+				},
+			},
+		}
+
+		_, err := getCountries(context.Background(), client, "http://upstream.test/countries", apiCountriesSchema, fetchOptions{limit: DefaultMaxResponseSize})
+
+		if ok {
+			assert.NoError(t, err, contentType)
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidResponse, contentType)
+		}
+	}
+}
+
+func TestFetchCountryDataWithClient_StopsAtFirstMatch(t *testing.T) {
+	// The body is cut off after the match, so reading on would fail.
+	mockResponse := `[{"name": "Niger", "capital": "Niamey"}, {"name": "Nigeria", "capital": "Abuja"}, {"name": `
+
+	client := &http.Client{
+		Transport: &MockRoundTripper{
+			RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(strings.NewReader(mockResponse)),
+				}, nil
+			},
+		},
+	}
+
+	result, err := FetchCountryDataWithClient("nigeria", client)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Abuja", result.Capital)
+}
@@ -0,0 +1,110 @@
+package externalapi
+
+import (
+	"CountrySearch/internal/httpfixture"
+	"context"
+	"flag"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run with -record to refresh testdata/fixtures from the live upstream.
+var record = flag.Bool("record", false, "record upstream exchanges to testdata/fixtures")
+
+const fixturesDir = "testdata/fixtures"
+
+func fixtureClient(t *testing.T) *http.Client {
+	t.Helper()
+	if *record {
+		return &http.Client{Transport: httpfixture.NewRecorder(fixturesDir, nil), Timeout: time.Minute}
+	}
+
+	rep, err := httpfixture.NewReplayer(fixturesDir)
+	require.NoError(t, err)
+	return &http.Client{Transport: rep}
+}
+
+func TestFixtures_SearchCountries(t *testing.T) {
+	p := NewAPICountriesProvider("", fixtureClient(t))
+
+	countries, err := p.SearchCountries(context.Background(), "France")
+
+	require.NoError(t, err)
+	france, err := FindCountry(countries, "France")
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", france.Capital)
+	assert.Equal(t, "FRA", france.Alpha3Code)
+	require.NotEmpty(t, france.Currencies)
+	assert.Equal(t, "EUR", france.Currencies[0].Code)
+}
+
+func TestFixtures_SearchReturnsEveryCandidate(t *testing.T) {
+	p := NewAPICountriesProvider("", fixtureClient(t))
+
+	countries, err := p.SearchCountries(context.Background(), "Guinea")
+
+	require.NoError(t, err)
+	var names []string
+	for _, c := range countries {
+		names = append(names, c.Name)
+	}
+	assert.Subset(t, names, []string{"Guinea", "Guinea-Bissau", "Equatorial Guinea", "Papua New Guinea"})
+}
+
+func TestFixtures_MultipleCurrencies(t *testing.T) {
+	p := NewAPICountriesProvider("", fixtureClient(t))
+
+	panama, err := FetchCountry(context.Background(), p, "Panama")
+
+	require.NoError(t, err)
+	var codes []string
+	for _, c := range panama.Currencies {
+		codes = append(codes, c.Code)
+		assert.NotNil(t, c.MinorUnits, c.Code)
+	}
+	assert.ElementsMatch(t, []string{"PAB", "USD"}, codes)
+}
+
+func TestFixtures_NotFound(t *testing.T) {
+	p := NewAPICountriesProvider("", fixtureClient(t))
+
+	_, err := p.SearchCountries(context.Background(), "Atlantis")
+
+	assert.ErrorIs(t, err, ErrCountryNotFound)
+}
+
+func TestFixtures_ListCountries(t *testing.T) {
+	p := NewAPICountriesProvider("", fixtureClient(t))
+
+	countries, err := p.ListCountries(context.Background())
+
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(countries), 8)
+	seen := make(map[string]bool, len(countries))
+	for _, c := range countries {
+		assert.False(t, seen[c.Alpha3Code], c.Alpha3Code)
+		seen[c.Alpha3Code] = true
+	}
+}
+
+func TestFixtures_UnknownFieldsAreDrift(t *testing.T) {
+	unknown := statValue("unknown.cioc")
+	p := NewAPICountriesProvider("", fixtureClient(t))
+
+	france, err := FetchCountry(context.Background(), p, "France")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", france.Capital)
+	assert.Equal(t, unknown+1, statValue("unknown.cioc"), "fields the schema doesn't decode are reported, not fatal")
+}
+
+func TestFixtures_FetchCountryDataWithClient(t *testing.T) {
+	result, err := FetchCountryDataWithClient("Korea", fixtureClient(t))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", result.Name, "no country is named exactly Korea")
+}
@@ -0,0 +1,89 @@
+package externalapi
+
+import (
+	"CountrySearch/internal/hedge"
+	"context"
+	"errors"
+)
+
+// HedgedProvider sends a second, identical search to backup when primary
+// is slower than its recent latency percentile, and keeps whichever
+// answers first. Backup may be primary itself.
+type HedgedProvider struct {
+	primary CountryProvider
+	backup  CountryProvider
+	policy  *hedge.Policy
+	// healthy, when set, reports whether a provider is passing its health
+	// probes; see RouteBy.
+	healthy func(name string) bool
+}
+
+func WithHedging(primary, backup CountryProvider, policy *hedge.Policy) *HedgedProvider {
+	if backup == nil {
+		backup = primary
+	}
+
+	return &HedgedProvider{
+		primary: primary,
+		backup:  backup,
+		policy:  policy,
+	}
+}
+
+func (p *HedgedProvider) Name() string {
+	return p.primary.Name()
+}
+
+func (p *HedgedProvider) Policy() *hedge.Policy {
+	return p.policy
+}
+
+// RouteBy makes searches start at the backup while the primary is failing
+// its health probes and the backup is not.
+func (p *HedgedProvider) RouteBy(healthy func(name string) bool) {
+	p.healthy = healthy
+}
+
+// order returns the provider to ask first and the one to hedge with.
+func (p *HedgedProvider) order() (CountryProvider, CountryProvider) {
+	if p.healthy == nil || p.primary.Name() == p.backup.Name() {
+		return p.primary, p.backup
+	}
+	if !p.healthy(p.primary.Name()) && p.healthy(p.backup.Name()) {
+		return p.backup, p.primary
+	}
+	return p.primary, p.backup
+}
+
+// hedgedAnswer carries each attempt's own CacheInfo, so the two calls
+// never write to the caller's at the same time.
+type hedgedAnswer struct {
+	countries []Country
+	info      *CacheInfo
+}
+
+func (p *HedgedProvider) SearchCountries(ctx context.Context, name string) ([]Country, error) {
+	attempt := func(provider CountryProvider) func(context.Context) (hedgedAnswer, error) {
+		return func(ctx context.Context) (hedgedAnswer, error) {
+			ctx, info := WithCacheInfo(ctx)
+			countries, err := provider.SearchCountries(ctx, name)
+			return hedgedAnswer{countries: countries, info: info}, err
+		}
+	}
+
+	first, second := p.order()
+	answer, err := hedge.Do(ctx, p.policy, attempt(first), attempt(second), func(err error) bool {
+		return errors.Is(err, ErrCountryNotFound)
+	})
+	if info := cacheInfoFrom(ctx); info != nil && answer.info != nil {
+		*info = *answer.info
+	}
+	return answer.countries, err
+}
+
+// ListCountries is a background bulk download where latency matters
+// little, and providers differ in how complete their lists are, so it is
+// never hedged.
+func (p *HedgedProvider) ListCountries(ctx context.Context) ([]Country, error) {
+	return p.primary.ListCountries(ctx)
+}
@@ -0,0 +1,126 @@
+package externalapi
+
+import (
+	"CountrySearch/internal/hedge"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eagerPolicy hedges every request after delay.
+func eagerPolicy(delay time.Duration) *hedge.Policy {
+	return hedge.New(hedge.Config{MinDelay: delay, MaxDelay: delay, MaxRate: 1, Window: 10, MinSamples: 100})
+}
+
+func slowUpstream(t *testing.T, delay time.Duration, body string, header http.Header) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		for name, values := range header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHedgedProvider_BackupAnswersSlowSearch(t *testing.T) {
+	slow := slowUpstream(t, time.Second, `[{"name": "France", "capital": "Slow"}]`, nil)
+	fast := slowUpstream(t, 0, `[{"name": "France", "capital": "Paris"}]`, http.Header{"Cache-Control": {"max-age=60"}})
+	p := WithHedging(
+		NewAPICountriesProvider(slow.URL, slow.Client()),
+		NewAPICountriesProvider(fast.URL, fast.Client()),
+		eagerPolicy(10*time.Millisecond),
+	)
+
+	ctx, info := WithCacheInfo(context.Background())
+	start := time.Now()
+	countries, err := p.SearchCountries(ctx, "France")
+
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, "Paris", countries[0].Capital)
+	assert.True(t, info.HasMaxAge, "the winner's cache info reaches the caller")
+	assert.Equal(t, 1, p.Policy().Snapshot().BackupWins)
+}
+
+func TestHedgedProvider_DefaultsBackupToPrimary(t *testing.T) {
+	primary := &stubProvider{search: func(ctx context.Context, name string) ([]Country, error) {
+		return []Country{{Name: name}}, nil
+	}}
+	p := WithHedging(primary, nil, eagerPolicy(time.Second))
+
+	countries, err := p.SearchCountries(context.Background(), "Chad")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Chad", countries[0].Name)
+	assert.Same(t, primary, p.backup)
+}
+
+func TestHedgedProvider_NotFoundIsAnAnswer(t *testing.T) {
+	backupCalled := make(chan struct{}, 1)
+	p := WithHedging(
+		&stubProvider{search: func(ctx context.Context, name string) ([]Country, error) {
+			return nil, ErrCountryNotFound
+		}},
+		&stubProvider{search: func(ctx context.Context, name string) ([]Country, error) {
+			backupCalled <- struct{}{}
+			return []Country{{Name: name}}, nil
+		}},
+		eagerPolicy(time.Second),
+	)
+
+	_, err := p.SearchCountries(context.Background(), "Atlantis")
+
+	assert.ErrorIs(t, err, ErrCountryNotFound)
+	assert.Empty(t, backupCalled)
+}
+
+func TestHedgedProvider_ListIsNotHedged(t *testing.T) {
+	primary := &stubProvider{search: func(ctx context.Context, name string) ([]Country, error) {
+		time.Sleep(30 * time.Millisecond)
+		return []Country{{Name: "Chad"}}, nil
+	}}
+	backup := &stubProvider{name: "backup", search: func(ctx context.Context, name string) ([]Country, error) {
+		return nil, nil
+	}}
+	p := WithHedging(primary, backup, eagerPolicy(time.Millisecond))
+
+	countries, err := p.ListCountries(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, countries, 1)
+	assert.Equal(t, 0, backup.calls)
+}
+
+func TestHedgedProvider_RoutesAroundUnhealthyPrimary(t *testing.T) {
+	primary := &stubProvider{name: "primary", search: func(ctx context.Context, name string) ([]Country, error) {
+		return []Country{{Name: name, Capital: "primary"}}, nil
+	}}
+	backup := &stubProvider{name: "backup", search: func(ctx context.Context, name string) ([]Country, error) {
+		return []Country{{Name: name, Capital: "backup"}}, nil
+	}}
+	p := WithHedging(primary, backup, eagerPolicy(time.Second))
+	down := map[string]bool{"primary": true}
+	p.RouteBy(func(name string) bool { return !down[name] })
+
+	countries, err := p.SearchCountries(context.Background(), "Chad")
+	require.NoError(t, err)
+	assert.Equal(t, "backup", countries[0].Capital)
+
+	down["backup"] = true
+	countries, err = p.SearchCountries(context.Background(), "Chad")
+	require.NoError(t, err)
+	assert.Equal(t, "primary", countries[0].Capital, "no better choice than the primary")
+}
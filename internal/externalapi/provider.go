@@ -0,0 +1,115 @@
+package externalapi
+
+import (
+	"CountrySearch/internal/httpclient"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrCountryNotFound means the upstream answered but had no matching country.
+	ErrCountryNotFound = errors.New("country not found")
+	// ErrUnavailable means the upstream was not asked at all, e.g. because a
+	// circuit breaker is open. Callers may fall back to stale data.
+	ErrUnavailable = errors.New("country upstream unavailable")
+)
+
+// CountryProvider is a source of country data.
+type CountryProvider interface {
+	Name() string
+	// SearchCountries returns every country the source considers a match
+	// for name, or ErrCountryNotFound when there are none.
+	SearchCountries(ctx context.Context, name string) ([]Country, error)
+	// ListCountries returns the source's entire dataset.
+	ListCountries(ctx context.Context) ([]Country, error)
+}
+
+// FetchCountry searches p for name and picks the case-insensitive exact
+// match. Providers that stream their response stop reading at that match.
+func FetchCountry(ctx context.Context, p CountryProvider, name string) (Country, error) {
+	countries, err := p.SearchCountries(context.WithValue(ctx, exactNameKey{}, name), name)
+	if err != nil {
+		return Country{}, err
+	}
+	return FindCountry(countries, name)
+}
+
+type exactNameKey struct{}
+
+// exactNameFrom returns the name FetchCountry is after, if the search was
+// made for it.
+func exactNameFrom(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(exactNameKey{}).(string)
+	return name, ok
+}
+
+// FindCountry picks the case-insensitive exact match for name.
+func FindCountry(countries []Country, name string) (Country, error) {
+	for _, country := range countries {
+		if strings.EqualFold(country.Name, name) {
+			return country, nil
+		}
+	}
+	return Country{}, ErrCountryNotFound
+}
+
+// APICountriesProvider fetches countries from apicountries.com.
+type APICountriesProvider struct {
+	baseURL         string
+	client          *http.Client
+	maxResponseSize int64
+	validators      *validatorCache
+}
+
+func NewAPICountriesProvider(baseURL string, client *http.Client) *APICountriesProvider {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if client == nil {
+		client = httpclient.Default()
+	}
+
+	return &APICountriesProvider{
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		client:          client,
+		maxResponseSize: DefaultMaxResponseSize,
+		validators:      newValidatorCache(defaultValidatorCapacity),
+	}
+}
+
+// SetMaxResponseSize bounds the response bodies p will read. Non-positive
+// sizes keep the default.
+func (p *APICountriesProvider) SetMaxResponseSize(size int64) {
+	if size > 0 {
+		p.maxResponseSize = size
+	}
+}
+
+func (p *APICountriesProvider) Name() string {
+	return "apicountries"
+}
+
+func (p *APICountriesProvider) SearchCountries(ctx context.Context, name string) ([]Country, error) {
+	opts := p.fetchOptions()
+	if exact, ok := exactNameFrom(ctx); ok {
+		opts.stop = func(c Country) bool { return strings.EqualFold(c.Name, exact) }
+	}
+	countries, err := searchCountries(ctx, p.client, p.baseURL, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(countries) == 0 {
+		return nil, ErrCountryNotFound
+	}
+	return countries, nil
+}
+
+func (p *APICountriesProvider) ListCountries(ctx context.Context) ([]Country, error) {
+	return listCountries(ctx, p.client, p.baseURL, p.fetchOptions())
+}
+
+func (p *APICountriesProvider) fetchOptions() fetchOptions {
+	return fetchOptions{limit: p.maxResponseSize, validators: p.validators}
+}
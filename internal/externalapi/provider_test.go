@@ -0,0 +1,173 @@
+package externalapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockClient(status int, body string) *http.Client {
+	return &http.Client{
+		Transport: &MockRoundTripper{
+			RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: status,
+					Body:       io.NopCloser(strings.NewReader(body)),
+				}, nil
+			},
+		},
+	}
+}
+
+func TestAPICountriesProvider_FetchCountry(t *testing.T) {
+	var requested string
+	client := &http.Client{
+		Transport: &MockRoundTripper{
+			RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+				requested = req.URL.String()
+				return &http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(strings.NewReader(`[{"name": "New Zealand", "capital": "Wellington"}]`)),
+				}, nil
+			},
+		},
+	}
+
+	p := NewAPICountriesProvider("http://upstream.test/", client)
+	result, err := FetchCountry(context.Background(), p, "New Zealand")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Wellington", result.Capital)
+	assert.Equal(t, "http://upstream.test/name/New%20Zealand", requested)
+}
+
+func TestAPICountriesProvider_NoMatchIsNotFound(t *testing.T) {
+	p := NewAPICountriesProvider("", mockClient(200, `[{"name": "France"}]`))
+
+	_, err := FetchCountry(context.Background(), p, "Atlantis")
+
+	assert.ErrorIs(t, err, ErrCountryNotFound)
+}
+
+func TestAPICountriesProvider_UpstreamNotFound(t *testing.T) {
+	p := NewAPICountriesProvider("", mockClient(404, `{"status": 404}`))
+
+	_, err := FetchCountry(context.Background(), p, "Atlantis")
+
+	assert.ErrorIs(t, err, ErrCountryNotFound)
+}
+
+func TestAPICountriesProvider_UpstreamServerError(t *testing.T) {
+	p := NewAPICountriesProvider("", mockClient(503, `{"status": 503}`))
+
+	_, err := FetchCountry(context.Background(), p, "France")
+
+	assert.ErrorIs(t, err, ErrUpstreamFailed)
+	assert.NotErrorIs(t, err, ErrCountryNotFound)
+}
+
+func TestAPICountriesProvider_UnexpectedStatuses(t *testing.T) {
+	for status, want := range map[int]error{
+		429: ErrUpstreamThrottled,
+		403: ErrUpstreamFailed,
+		302: ErrUpstreamFailed,
+	} {
+		p := NewAPICountriesProvider("", mockClient(status, `{}`))
+
+		_, err := FetchCountry(context.Background(), p, "France")
+
+		assert.ErrorIs(t, err, want, status)
+		assert.NotErrorIs(t, err, ErrCountryNotFound, status)
+	}
+}
+
+func TestAPICountriesProvider_TransportError(t *testing.T) {
+	client := &http.Client{Transport: &MockRoundTripper{
+		RoundTripFunc: func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		},
+	}}
+	p := NewAPICountriesProvider("", client)
+
+	_, err := FetchCountry(context.Background(), p, "France")
+
+	assert.ErrorIs(t, err, ErrUpstreamFailed)
+	assert.NotErrorIs(t, err, ErrCountryNotFound)
+}
+
+func TestAPICountriesProvider_FetchCountryStopsAtExactMatch(t *testing.T) {
+	// Anything read past Guinea would fail, as the body is cut short.
+	p := NewAPICountriesProvider("", mockClient(200, `[{"name": "Guinea-Bissau"}, {"name": "Guinea"}, {"name": "Equa`))
+
+	country, err := FetchCountry(context.Background(), p, "guinea")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Guinea", country.Name)
+
+	_, err = p.SearchCountries(context.Background(), "guinea")
+	assert.Error(t, err)
+}
+
+func TestAPICountriesProvider_Name(t *testing.T) {
+	assert.Equal(t, "apicountries", NewAPICountriesProvider("", nil).Name())
+}
+
+func TestAPICountriesProvider_SearchCountriesReturnsAllCandidates(t *testing.T) {
+	p := NewAPICountriesProvider("", mockClient(200, `[
+		{"name": "Guinea"},
+		{"name": "Guinea-Bissau"},
+		{"name": "Equatorial Guinea"}
+	]`))
+
+	countries, err := p.SearchCountries(context.Background(), "Guinea")
+
+	assert.NoError(t, err)
+	assert.Len(t, countries, 3)
+}
+
+func TestAPICountriesProvider_SearchCountriesEmptyIsNotFound(t *testing.T) {
+	p := NewAPICountriesProvider("", mockClient(200, `[]`))
+
+	_, err := p.SearchCountries(context.Background(), "Atlantis")
+
+	assert.ErrorIs(t, err, ErrCountryNotFound)
+}
+
+func TestFindCountry(t *testing.T) {
+	countries := []Country{{Name: "Niger"}, {Name: "Nigeria"}}
+
+	country, err := FindCountry(countries, "NIGERIA")
+	assert.NoError(t, err)
+	assert.Equal(t, "Nigeria", country.Name)
+
+	_, err = FindCountry(countries, "Nige")
+	assert.ErrorIs(t, err, ErrCountryNotFound)
+}
+
+func TestAPICountriesProvider_ListCountries(t *testing.T) {
+	var requested string
+	client := &http.Client{
+		Transport: &MockRoundTripper{
+			RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+				requested = req.URL.String()
+				return &http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(strings.NewReader(`[{"name": "Chad", "alpha3Code": "TCD"}, {"name": "Chile", "alpha3Code": "CHL"}]`)),
+				}, nil
+			},
+		},
+	}
+	p := NewAPICountriesProvider("http://upstream.test", client)
+
+	countries, err := p.ListCountries(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "http://upstream.test/countries", requested)
+	assert.Len(t, countries, 2)
+	assert.Equal(t, "CHL", countries[1].Alpha3Code)
+}
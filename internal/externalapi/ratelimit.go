@@ -0,0 +1,53 @@
+package externalapi
+
+import (
+	"CountrySearch/internal/ratelimit"
+	"context"
+	"fmt"
+)
+
+// RateLimitProvider spends outbound budget from a shared Limiter before
+// every upstream call. Calls over budget fail with ErrUnavailable.
+type RateLimitProvider struct {
+	next    CountryProvider
+	limiter *ratelimit.Limiter
+}
+
+func WithRateLimit(next CountryProvider, l *ratelimit.Limiter) *RateLimitProvider {
+	return &RateLimitProvider{
+		next:    next,
+		limiter: l,
+	}
+}
+
+func (p *RateLimitProvider) Name() string {
+	return p.next.Name()
+}
+
+func (p *RateLimitProvider) Limiter() *ratelimit.Limiter {
+	return p.limiter
+}
+
+func (p *RateLimitProvider) SearchCountries(ctx context.Context, name string) ([]Country, error) {
+	if err := p.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return p.next.SearchCountries(ctx, name)
+}
+
+func (p *RateLimitProvider) ListCountries(ctx context.Context) ([]Country, error) {
+	if err := p.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return p.next.ListCountries(ctx)
+}
+
+func (p *RateLimitProvider) acquire(ctx context.Context) error {
+	if err := p.limiter.Acquire(ctx); err != nil {
+		if ratelimit.IsLimited(err) {
+			return fmt.Errorf("%s: %w: %w", p.Name(), ErrUnavailable, err)
+		}
+		return err
+	}
+	return nil
+}
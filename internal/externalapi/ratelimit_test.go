@@ -0,0 +1,57 @@
+package externalapi
+
+import (
+	"CountrySearch/internal/breaker"
+	"CountrySearch/internal/ratelimit"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitProvider_RejectsOverBudget(t *testing.T) {
+	stub := &stubProvider{search: func(ctx context.Context, name string) ([]Country, error) {
+		return []Country{{Name: name}}, nil
+	}}
+	p := WithRateLimit(stub, ratelimit.New(ratelimit.Config{Rate: 0.001, Burst: 2, Mode: ratelimit.ModeStale}))
+
+	for i := 0; i < 2; i++ {
+		_, err := p.SearchCountries(context.Background(), "Peru")
+		require.NoError(t, err)
+	}
+	_, err := p.SearchCountries(context.Background(), "Peru")
+
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, err, ratelimit.ErrLimited)
+	assert.Equal(t, 2, stub.calls)
+}
+
+func TestRateLimitProvider_ShareBudgetAcrossCallers(t *testing.T) {
+	stub := &stubProvider{search: func(ctx context.Context, name string) ([]Country, error) {
+		return []Country{{Name: name}}, nil
+	}}
+	limiter := ratelimit.New(ratelimit.Config{DailyQuota: 1, Mode: ratelimit.ModeFail})
+	a := WithRateLimit(stub, limiter)
+	b := WithRateLimit(stub, limiter)
+
+	_, err := a.SearchCountries(context.Background(), "Peru")
+	require.NoError(t, err)
+	_, err = b.SearchCountries(context.Background(), "Chile")
+
+	assert.ErrorIs(t, err, ratelimit.ErrQuotaExhausted)
+}
+
+func TestBreakerProvider_IgnoresRateLimitedCalls(t *testing.T) {
+	stub := &stubProvider{search: func(ctx context.Context, name string) ([]Country, error) {
+		return []Country{{Name: name}}, nil
+	}}
+	limited := WithRateLimit(stub, ratelimit.New(ratelimit.Config{DailyQuota: 1, Mode: ratelimit.ModeFail}))
+	p := WithBreaker(limited, tightBreaker())
+
+	for i := 0; i < 5; i++ {
+		_, _ = p.SearchCountries(context.Background(), "Peru")
+	}
+
+	assert.Equal(t, breaker.StateClosed, p.Breaker().State())
+}
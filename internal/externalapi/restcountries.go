@@ -0,0 +1,184 @@
+package externalapi
+
+import (
+	"CountrySearch/internal/httpclient"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DefaultRESTCountriesBaseURL is the public REST Countries endpoint.
+const DefaultRESTCountriesBaseURL = "https://restcountries.com"
+
+// restCountriesListFields is what ListCountries asks for; REST Countries
+// caps /all at ten fields.
+const restCountriesListFields = "name,cca2,cca3,ccn3,capital,region,subregion,population,area,currencies"
+
+// RESTCountriesResponse is a country as returned by REST Countries v3.1.
+type RESTCountriesResponse struct {
+	Name struct {
+		Common     string `json:"common"`
+		Official   string `json:"official"`
+		NativeName map[string]struct {
+			Official string `json:"official"`
+			Common   string `json:"common"`
+		} `json:"nativeName"`
+	} `json:"name"`
+	TLD        []string `json:"tld"`
+	CCA2       string   `json:"cca2"`
+	CCN3       string   `json:"ccn3"`
+	CCA3       string   `json:"cca3"`
+	Currencies map[string]struct {
+		Name   string `json:"name"`
+		Symbol string `json:"symbol"`
+	} `json:"currencies"`
+	IDD struct {
+		Root     string   `json:"root"`
+		Suffixes []string `json:"suffixes"`
+	} `json:"idd"`
+	Capital      []string          `json:"capital"`
+	AltSpellings []string          `json:"altSpellings"`
+	Region       string            `json:"region"`
+	Subregion    string            `json:"subregion"`
+	Languages    map[string]string `json:"languages"`
+	LatLng       []float64         `json:"latlng"`
+	Borders      []string          `json:"borders"`
+	Area         float64           `json:"area"`
+	Demonyms     map[string]struct {
+		F string `json:"f"`
+		M string `json:"m"`
+	} `json:"demonyms"`
+	Population int      `json:"population"`
+	Timezones  []string `json:"timezones"`
+	Flags      struct {
+		PNG string `json:"png"`
+		SVG string `json:"svg"`
+	} `json:"flags"`
+}
+
+// Country converts the REST Countries record to the public model.
+func (r RESTCountriesResponse) Country() Country {
+	country := Country{
+		Name:            r.Name.Common,
+		AltSpellings:    r.AltSpellings,
+		Alpha2Code:      r.CCA2,
+		Alpha3Code:      r.CCA3,
+		NumericCode:     r.CCN3,
+		Region:          r.Region,
+		Subregion:       r.Subregion,
+		Population:      r.Population,
+		Area:            r.Area,
+		LatLng:          r.LatLng,
+		Timezones:       r.Timezones,
+		TopLevelDomains: r.TLD,
+		Borders:         r.Borders,
+		Flags:           Flags{SVG: r.Flags.SVG, PNG: r.Flags.PNG},
+	}
+	if len(r.Capital) > 0 {
+		country.Capital = r.Capital[0]
+	}
+	if d, ok := r.Demonyms["eng"]; ok {
+		country.Demonym = d.M
+	}
+
+	root := strings.TrimPrefix(r.IDD.Root, "+")
+	if root != "" {
+		if len(r.IDD.Suffixes) == 1 {
+			country.CallingCodes = []string{root + r.IDD.Suffixes[0]}
+		} else {
+			country.CallingCodes = []string{root}
+		}
+	}
+
+	for _, code := range sortedKeys(r.Languages) {
+		country.Languages = append(country.Languages, Language{ISO639_2: code, Name: r.Languages[code]})
+	}
+	if len(country.Languages) > 0 {
+		if native, ok := r.Name.NativeName[country.Languages[0].ISO639_2]; ok {
+			country.NativeName = native.Common
+		}
+	}
+	for _, code := range sortedKeys(r.Currencies) {
+		c := r.Currencies[code]
+		country.Currencies = append(country.Currencies, completeCurrency(Currency{
+			Code:   code,
+			Name:   c.Name,
+			Symbol: c.Symbol,
+		}))
+	}
+	return country
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RESTCountriesProvider fetches countries from REST Countries.
+type RESTCountriesProvider struct {
+	baseURL         string
+	client          *http.Client
+	maxResponseSize int64
+	validators      *validatorCache
+}
+
+func NewRESTCountriesProvider(baseURL string, client *http.Client) *RESTCountriesProvider {
+	if baseURL == "" {
+		baseURL = DefaultRESTCountriesBaseURL
+	}
+	if client == nil {
+		client = httpclient.Default()
+	}
+
+	return &RESTCountriesProvider{
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		client:          client,
+		maxResponseSize: DefaultMaxResponseSize,
+		validators:      newValidatorCache(defaultValidatorCapacity),
+	}
+}
+
+// SetMaxResponseSize bounds the response bodies p will read. Non-positive
+// sizes keep the default.
+func (p *RESTCountriesProvider) SetMaxResponseSize(size int64) {
+	if size > 0 {
+		p.maxResponseSize = size
+	}
+}
+
+func (p *RESTCountriesProvider) Name() string {
+	return "restcountries"
+}
+
+func (p *RESTCountriesProvider) SearchCountries(ctx context.Context, name string) ([]Country, error) {
+	if name == "" {
+		return nil, fmt.Errorf("country name cannot be empty")
+	}
+	opts := p.fetchOptions()
+	if exact, ok := exactNameFrom(ctx); ok {
+		opts.stop = func(c Country) bool { return strings.EqualFold(c.Name, exact) }
+	}
+	countries, err := getCountries(ctx, p.client, p.baseURL+"/v3.1/name/"+url.PathEscape(name), restCountriesSchema, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(countries) == 0 {
+		return nil, ErrCountryNotFound
+	}
+	return countries, nil
+}
+
+func (p *RESTCountriesProvider) ListCountries(ctx context.Context) ([]Country, error) {
+	return getCountries(ctx, p.client, p.baseURL+"/v3.1/all?fields="+restCountriesListFields, restCountriesSchema, p.fetchOptions())
+}
+
+func (p *RESTCountriesProvider) fetchOptions() fetchOptions {
+	return fetchOptions{limit: p.maxResponseSize, validators: p.validators}
+}
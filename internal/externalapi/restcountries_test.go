@@ -0,0 +1,139 @@
+package externalapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const panamaREST = `[{
+	"name": {"common": "Panama", "official": "Republic of Panama",
+		"nativeName": {"spa": {"official": "República de Panamá", "common": "Panamá"}}},
+	"tld": [".pa"],
+	"cca2": "PA", "ccn3": "591", "cca3": "PAN",
+	"currencies": {"USD": {"name": "United States dollar", "symbol": "$"}, "PAB": {"name": "Panamanian balboa", "symbol": "B/."}},
+	"idd": {"root": "+5", "suffixes": ["07"]},
+	"capital": ["Panama City"],
+	"altSpellings": ["PA", "Republic of Panama"],
+	"region": "Americas", "subregion": "Central America",
+	"languages": {"spa": "Spanish"},
+	"latlng": [9, -80],
+	"borders": ["COL", "CRI"],
+	"area": 75417,
+	"demonyms": {"eng": {"f": "Panamanian", "m": "Panamanian"}},
+	"population": 4314768,
+	"timezones": ["UTC-05:00"],
+	"flags": {"png": "https://flagcdn.com/w320/pa.png", "svg": "https://flagcdn.com/pa.svg"}
+}]`
+
+func restUpstream(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+		if r.URL.Path == "/v3.1/name/Atlantis" {
+			http.Error(w, `{"status":404,"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(panamaREST))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requested
+}
+
+func TestRESTCountriesProvider_SearchCountries(t *testing.T) {
+	srv, requested := restUpstream(t)
+	p := NewRESTCountriesProvider(srv.URL, srv.Client())
+
+	countries, err := p.SearchCountries(context.Background(), "Panama")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/v3.1/name/Panama"}, *requested)
+	require.Len(t, countries, 1)
+	panama := countries[0]
+	assert.Equal(t, "Panama", panama.Name)
+	assert.Equal(t, "Panamá", panama.NativeName)
+	assert.Equal(t, "PAN", panama.Alpha3Code)
+	assert.Equal(t, "Panama City", panama.Capital)
+	assert.Equal(t, []string{"507"}, panama.CallingCodes)
+	assert.Equal(t, "Panamanian", panama.Demonym)
+	assert.Equal(t, []Language{{ISO639_2: "spa", Name: "Spanish"}}, panama.Languages)
+	require.Len(t, panama.Currencies, 2)
+	assert.Equal(t, "PAB", panama.Currencies[0].Code)
+	assert.Equal(t, "USD", panama.Currencies[1].Code)
+	require.NotNil(t, panama.Currencies[1].MinorUnits, "filled in from ISO 4217")
+	assert.Equal(t, 2, *panama.Currencies[1].MinorUnits)
+}
+
+func TestRESTCountriesProvider_NotFound(t *testing.T) {
+	srv, _ := restUpstream(t)
+	p := NewRESTCountriesProvider(srv.URL, srv.Client())
+
+	_, err := p.SearchCountries(context.Background(), "Atlantis")
+
+	assert.ErrorIs(t, err, ErrCountryNotFound)
+}
+
+func TestRESTCountriesProvider_ListCountriesAsksForFields(t *testing.T) {
+	srv, requested := restUpstream(t)
+	p := NewRESTCountriesProvider(srv.URL, srv.Client())
+
+	countries, err := p.ListCountries(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, countries, 1)
+	assert.Equal(t, []string{"/v3.1/all?fields=" + restCountriesListFields}, *requested)
+}
+
+func TestRESTCountriesProvider_Defaults(t *testing.T) {
+	p := NewRESTCountriesProvider("", nil)
+
+	assert.Equal(t, "restcountries", p.Name())
+	assert.Equal(t, DefaultRESTCountriesBaseURL, p.baseURL)
+}
+
+func TestRESTCountriesProvider_ValidatesRecords(t *testing.T) {
+	rejected := mapValue(restCountriesSchema.stats, "invalid.cca3")
+	p := NewRESTCountriesProvider("", mockClient(200, `[
+		{"name": {"common": "Panama"}, "cca3": "PAN", "population": 4314768},
+		{"name": {"common": "Oddland"}, "cca3": "ODDL"},
+		{"name": {"common": ""}}
+	]`))
+
+	countries, err := p.ListCountries(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, countries, 1)
+	assert.Equal(t, "Panama", countries[0].Name)
+	assert.Equal(t, rejected+1, mapValue(restCountriesSchema.stats, "invalid.cca3"))
+}
+
+func TestRESTCountriesProvider_RevalidatesWithETag(t *testing.T) {
+	var notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(panamaREST))
+	}))
+	defer srv.Close()
+	p := NewRESTCountriesProvider(srv.URL, srv.Client())
+
+	_, err := p.ListCountries(context.Background())
+	require.NoError(t, err)
+	countries, err := p.ListCountries(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, notModified)
+	require.Len(t, countries, 1)
+	assert.Equal(t, "Panama", countries[0].Name)
+}
@@ -0,0 +1,135 @@
+package externalapi
+
+import (
+	"CountrySearch/internal/cache"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultValidatorCapacity is how many upstream URLs the provider keeps
+// validators for.
+const defaultValidatorCapacity = 512
+
+// CacheInfo is what the upstream said about reusing a response. Providers
+// fill it in when the caller asked for it with WithCacheInfo.
+type CacheInfo struct {
+	// MaxAge is how much longer the response is fresh, from Cache-Control
+	// max-age less Age. It is only meaningful when HasMaxAge is set.
+	MaxAge    time.Duration
+	HasMaxAge bool
+	// NotModified is set when a conditional request was answered with 304
+	// and the previous response was reused.
+	NotModified bool
+}
+
+type cacheInfoKey struct{}
+
+// WithCacheInfo returns a context that collects the CacheInfo of the
+// upstream response fetched with it.
+func WithCacheInfo(ctx context.Context) (context.Context, *CacheInfo) {
+	info := &CacheInfo{}
+	return context.WithValue(ctx, cacheInfoKey{}, info), info
+}
+
+// cacheInfoFrom returns the context's CacheInfo, or nil if nobody asked.
+func cacheInfoFrom(ctx context.Context) *CacheInfo {
+	info, _ := ctx.Value(cacheInfoKey{}).(*CacheInfo)
+	return info
+}
+
+// record reads the freshness directives from a response's headers.
+func (info *CacheInfo) record(h http.Header) {
+	if info == nil {
+		return
+	}
+
+	maxAge, ok := parseMaxAge(h.Get("Cache-Control"))
+	if !ok {
+		return
+	}
+	if age, err := strconv.Atoi(h.Get("Age")); err == nil && age > 0 {
+		maxAge -= time.Duration(age) * time.Second
+	}
+	info.MaxAge = max(maxAge, 0)
+	info.HasMaxAge = true
+}
+
+// parseMaxAge returns the max-age of a Cache-Control header. no-cache and
+// no-store count as a max-age of zero.
+func parseMaxAge(cacheControl string) (time.Duration, bool) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache", "no-store":
+			return 0, true
+		case "max-age":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil || seconds < 0 {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
+// validated is an upstream response's validators and the countries it held,
+// so a 304 can be answered without the body. A partial response was only
+// read up to the record its search was after.
+type validated struct {
+	etag         string
+	lastModified string
+	countries    []Country
+	partial      bool
+}
+
+func (v validated) apply(req *http.Request) {
+	if v.etag != "" {
+		req.Header.Set("If-None-Match", v.etag)
+	}
+	if v.lastModified != "" {
+		req.Header.Set("If-Modified-Since", v.lastModified)
+	}
+}
+
+// validatorCache holds the last validated response per URL. A nil cache
+// stores nothing.
+type validatorCache struct {
+	lru *cache.LRUCache
+}
+
+func newValidatorCache(capacity int) *validatorCache {
+	return &validatorCache{lru: cache.NewLRUCache(capacity)}
+}
+
+func (c *validatorCache) get(url string) (validated, bool) {
+	if c == nil {
+		return validated{}, false
+	}
+	value, ok := c.lru.Get(url)
+	if !ok {
+		return validated{}, false
+	}
+	v, ok := value.(validated)
+	return v, ok
+}
+
+// put stores countries under url if the response carried validators.
+func (c *validatorCache) put(url string, h http.Header, countries []Country, partial bool) {
+	if c == nil {
+		return
+	}
+	v := validated{
+		etag:         h.Get("ETag"),
+		lastModified: h.Get("Last-Modified"),
+		countries:    countries,
+		partial:      partial,
+	}
+	if v.etag == "" && v.lastModified == "" {
+		return
+	}
+	c.lru.Set(url, v)
+}
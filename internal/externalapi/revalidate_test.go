@@ -0,0 +1,134 @@
+package externalapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validatingUpstream serves one country with the given validator headers
+// and answers 304 to a matching conditional request.
+func validatingUpstream(t *testing.T, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, values := range header {
+			w.Header()[name] = values
+		}
+		etag := header.Get("ETag")
+		lastModified := header.Get("Last-Modified")
+		if (etag != "" && r.Header.Get("If-None-Match") == etag) ||
+			(lastModified != "" && r.Header.Get("If-Modified-Since") == lastModified) {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"name": "France", "capital": "Paris"}]`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &notModified
+}
+
+func TestAPICountriesProvider_RevalidatesWithETag(t *testing.T) {
+	srv, notModified := validatingUpstream(t, http.Header{
+		"Etag":          {`"v1"`},
+		"Cache-Control": {"public, max-age=300"},
+	})
+	p := NewAPICountriesProvider(srv.URL, srv.Client())
+
+	ctx, info := WithCacheInfo(context.Background())
+	_, err := p.SearchCountries(ctx, "France")
+	require.NoError(t, err)
+	assert.False(t, info.NotModified)
+	assert.True(t, info.HasMaxAge)
+	assert.Equal(t, 5*time.Minute, info.MaxAge)
+
+	ctx, info = WithCacheInfo(context.Background())
+	countries, err := p.SearchCountries(ctx, "France")
+	require.NoError(t, err)
+	assert.True(t, info.NotModified)
+	assert.Equal(t, int32(1), notModified.Load())
+	require.Len(t, countries, 1)
+	assert.Equal(t, "Paris", countries[0].Capital)
+}
+
+func TestAPICountriesProvider_PartialResponseOnlyRevalidatesExactSearch(t *testing.T) {
+	srv, notModified := validatingUpstream(t, http.Header{"Etag": {`"v1"`}})
+	p := NewAPICountriesProvider(srv.URL, srv.Client())
+
+	_, err := FetchCountry(context.Background(), p, "France")
+	require.NoError(t, err)
+	_, err = FetchCountry(context.Background(), p, "France")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), notModified.Load())
+
+	countries, err := p.SearchCountries(context.Background(), "France")
+	require.NoError(t, err)
+	assert.Len(t, countries, 1)
+	assert.Equal(t, int32(1), notModified.Load(), "a full search is not answered from a partial read")
+}
+
+func TestAPICountriesProvider_RevalidatesWithLastModified(t *testing.T) {
+	srv, notModified := validatingUpstream(t, http.Header{
+		"Last-Modified": {"Wed, 01 Jan 2025 00:00:00 GMT"},
+	})
+	p := NewAPICountriesProvider(srv.URL, srv.Client())
+
+	_, err := p.ListCountries(context.Background())
+	require.NoError(t, err)
+	countries, err := p.ListCountries(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, countries, 1)
+	assert.Equal(t, int32(1), notModified.Load())
+}
+
+func TestAPICountriesProvider_NoValidatorsNoConditionalRequest(t *testing.T) {
+	srv, notModified := validatingUpstream(t, http.Header{})
+	p := NewAPICountriesProvider(srv.URL, srv.Client())
+
+	for i := 0; i < 2; i++ {
+		ctx, info := WithCacheInfo(context.Background())
+		_, err := p.SearchCountries(ctx, "France")
+		require.NoError(t, err)
+		assert.False(t, info.HasMaxAge)
+	}
+	assert.Equal(t, int32(0), notModified.Load())
+}
+
+func TestCacheInfo_AgeShortensMaxAge(t *testing.T) {
+	var info CacheInfo
+
+	info.record(http.Header{"Cache-Control": {"max-age=60"}, "Age": {"45"}})
+
+	assert.True(t, info.HasMaxAge)
+	assert.Equal(t, 15*time.Second, info.MaxAge)
+}
+
+func TestParseMaxAge(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"max-age=60", time.Minute, true},
+		{"public, max-age=3600", time.Hour, true},
+		{"no-store", 0, true},
+		{"no-cache, max-age=60", 0, true},
+		{"public", 0, false},
+		{"max-age=soon", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseMaxAge(tt.header)
+		assert.Equal(t, tt.ok, ok, tt.header)
+		assert.Equal(t, tt.want, got, tt.header)
+	}
+}
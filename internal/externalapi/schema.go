@@ -0,0 +1,338 @@
+package externalapi
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidResponse means the upstream answered with data that failed
+// validation, so there was nothing safe to serve or cache.
+var ErrInvalidResponse = errors.New("invalid upstream response")
+
+const (
+	// maxPopulation is comfortably above any real country.
+	maxPopulation = 2_000_000_000
+	// maxArea is a little more than Russia, in km².
+	maxArea = 20_000_000
+)
+
+// recordSchema is what the shared decoder knows about one upstream's
+// record type T: its fields, how to report drift in it and how to map it
+// to a Country.
+type recordSchema[T any] struct {
+	driftSchema
+	// fields maps every JSON field T decodes to the index of its struct
+	// field.
+	fields map[string]int
+	// names gives the upstream's name for the Country JSON fields
+	// checkCountry reports, where the two differ.
+	names   map[string]string
+	country func(T) Country
+}
+
+// driftSchema says how drift in an upstream's responses is reported.
+type driftSchema struct {
+	// source names the upstream in logs.
+	source string
+	// core are the upstream fields the v1 response is built from. One
+	// missing from every record of a response is reported as drift.
+	core []string
+	// stats counts decoded records and drift, published on /debug/vars.
+	stats *expvar.Map
+}
+
+// schemaStats counts drift in apicountries.com responses.
+var schemaStats = expvar.NewMap("upstream_schema")
+
+var apiCountriesSchema = &recordSchema[CountryAPIResponse]{
+	driftSchema: driftSchema{
+		source: "apicountries",
+		core:   []string{"name", "capital", "population", "currencies"},
+		stats:  schemaStats,
+	},
+	fields:  jsonFields(reflect.TypeFor[CountryAPIResponse]()),
+	names:   map[string]string{"alpha2_code": "alpha2Code", "alpha3_code": "alpha3Code"},
+	country: CountryAPIResponse.Country,
+}
+
+var restCountriesSchema = &recordSchema[RESTCountriesResponse]{
+	driftSchema: driftSchema{
+		source: "restcountries",
+		core:   []string{"name", "capital", "population", "currencies"},
+		stats:  expvar.NewMap("upstream_schema_restcountries"),
+	},
+	fields:  jsonFields(reflect.TypeFor[RESTCountriesResponse]()),
+	names:   map[string]string{"name": "name.common", "alpha2_code": "cca2", "alpha3_code": "cca3"},
+	country: RESTCountriesResponse.Country,
+}
+
+// loggedDrift holds the fields of every drift already logged, so drift
+// that persists across responses is logged once rather than every time.
+var loggedDrift sync.Map
+
+// DriftReport describes how an upstream response differed from the
+// schema of its records.
+type DriftReport struct {
+	Records  int
+	Rejected int
+	// Unknown counts records per field the schema does not know.
+	Unknown map[string]int
+	// Missing lists core fields absent from every record.
+	Missing []string
+	// Invalid counts records rejected per offending field.
+	Invalid map[string]int
+}
+
+func (d DriftReport) Empty() bool {
+	return d.Rejected == 0 && len(d.Unknown) == 0 && len(d.Missing) == 0
+}
+
+func (d DriftReport) String() string {
+	var parts []string
+	if d.Rejected > 0 {
+		parts = append(parts, fmt.Sprintf("rejected %d of %d records %v", d.Rejected, d.Records, d.Invalid))
+	}
+	if len(d.Unknown) > 0 {
+		parts = append(parts, fmt.Sprintf("unknown fields %v", d.Unknown))
+	}
+	if len(d.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("missing fields %v", d.Missing))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// decodeCountries streams an upstream country array one record at a time,
+// mapping each to a Country and dropping those that fail validation. It
+// stops early once stop, when given, accepts a country. The response is
+// only an error if it is not an array of objects or every record was
+// rejected.
+func decodeCountries[T any](r io.Reader, schema *recordSchema[T], stop func(Country) bool) ([]Country, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('[') {
+		return nil, fmt.Errorf("%w: expected a JSON array, got %v", ErrInvalidResponse, tok)
+	}
+
+	drift := newDriftReport()
+	seen := make(map[string]int, len(schema.fields))
+	var countries []Country
+	for dec.More() {
+		country, ok, err := decodeRecord(dec, schema, &drift, seen)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		countries = append(countries, country)
+		if stop != nil && stop(country) {
+			return countries, drift.finish(schema.driftSchema, seen)
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return countries, drift.finish(schema.driftSchema, seen)
+}
+
+func newDriftReport() DriftReport {
+	return DriftReport{
+		Unknown: make(map[string]int),
+		Invalid: make(map[string]int),
+	}
+}
+
+// decodeRecord reads the next record from dec in a single pass, field by
+// field, and maps it to a Country, noting in drift anything unexpected.
+// seen counts the records each field appeared in. A record that fails
+// validation is skipped and reported as not ok; only a malformed stream is
+// an error.
+func decodeRecord[T any](dec *json.Decoder, schema *recordSchema[T], drift *DriftReport, seen map[string]int) (Country, bool, error) {
+	drift.Records++
+
+	tok, err := dec.Token()
+	if err != nil {
+		return Country{}, false, err
+	}
+	if tok != json.Delim('{') {
+		drift.reject("record")
+		return Country{}, false, skipRest(dec, tok)
+	}
+
+	var record T
+	fields := reflect.ValueOf(&record).Elem()
+	invalid := ""
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return Country{}, false, err
+		}
+		field := tok.(string)
+		seen[field]++
+
+		i, known := schema.fields[field]
+		if !known {
+			drift.Unknown[field]++
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return Country{}, false, err
+			}
+			continue
+		}
+		if err := dec.Decode(fields.Field(i).Addr().Interface()); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(err, &typeErr) {
+				return Country{}, false, err
+			}
+			if invalid == "" {
+				invalid = field
+			}
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return Country{}, false, err
+	}
+	if invalid != "" {
+		drift.reject(invalid)
+		return Country{}, false, nil
+	}
+
+	country := schema.country(record)
+	if field, err := checkCountry(country); err != nil {
+		drift.reject(schema.upstreamName(field))
+		return Country{}, false, nil
+	}
+	return country, true, nil
+}
+
+// upstreamName returns the upstream's name for a Country JSON field.
+func (s *recordSchema[T]) upstreamName(field string) string {
+	if name, ok := s.names[field]; ok {
+		return name
+	}
+	return field
+}
+
+// skipRest consumes the rest of a value whose first token was tok.
+func skipRest(dec *json.Decoder, tok json.Token) error {
+	depth := 0
+	for {
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+			default:
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+		var err error
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+	}
+}
+
+func (d *DriftReport) reject(field string) {
+	d.Rejected++
+	d.Invalid[field]++
+}
+
+// finish completes the report once every record is decoded, then logs
+// and counts it against schema.
+func (d *DriftReport) finish(schema driftSchema, seen map[string]int) error {
+	if d.Records > 0 {
+		for _, field := range schema.core {
+			if seen[field] == 0 {
+				d.Missing = append(d.Missing, field)
+			}
+		}
+	}
+	sort.Strings(d.Missing)
+
+	schema.stats.Add("records", int64(d.Records))
+	schema.stats.Add("rejected", int64(d.Rejected))
+	for field, n := range d.Unknown {
+		schema.stats.Add("unknown."+field, int64(n))
+	}
+	for _, field := range d.Missing {
+		schema.stats.Add("missing."+field, 1)
+	}
+	for field, n := range d.Invalid {
+		schema.stats.Add("invalid."+field, int64(n))
+	}
+
+	if d.Empty() {
+		return nil
+	}
+	if _, logged := loggedDrift.LoadOrStore(schema.source+":"+d.fields(), true); !logged {
+		log.Printf("upstream schema drift from %s: %s", schema.source, d)
+	}
+	if d.Rejected == d.Records {
+		return fmt.Errorf("%w: all %d records rejected", ErrInvalidResponse, d.Records)
+	}
+	return nil
+}
+
+// fields identifies the drift by the fields involved, whatever the counts.
+func (d *DriftReport) fields() string {
+	var fields []string
+	for field := range d.Unknown {
+		fields = append(fields, "unknown."+field)
+	}
+	for _, field := range d.Missing {
+		fields = append(fields, "missing."+field)
+	}
+	for field := range d.Invalid {
+		fields = append(fields, "invalid."+field)
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, ",")
+}
+
+// checkCountry checks a decoded country is plausible, returning the
+// offending Country JSON field with the error.
+func checkCountry(c Country) (string, error) {
+	switch {
+	case strings.TrimSpace(c.Name) == "":
+		return "name", fmt.Errorf("country has no name")
+	case c.Population < 0 || c.Population > maxPopulation:
+		return "population", fmt.Errorf("%s: population %d out of range", c.Name, c.Population)
+	case c.Area < 0 || c.Area > maxArea:
+		return "area", fmt.Errorf("%s: area %v out of range", c.Name, c.Area)
+	case c.Alpha2Code != "" && len(c.Alpha2Code) != 2:
+		return "alpha2_code", fmt.Errorf("%s: malformed alpha-2 code %q", c.Name, c.Alpha2Code)
+	case c.Alpha3Code != "" && len(c.Alpha3Code) != 3:
+		return "alpha3_code", fmt.Errorf("%s: malformed alpha-3 code %q", c.Name, c.Alpha3Code)
+	}
+	if len(c.LatLng) > 0 {
+		if len(c.LatLng) != 2 || c.LatLng[0] < -90 || c.LatLng[0] > 90 || c.LatLng[1] < -180 || c.LatLng[1] > 180 {
+			return "latlng", fmt.Errorf("%s: invalid coordinates %v", c.Name, c.LatLng)
+		}
+	}
+	return "", nil
+}
+
+// jsonFields maps the top-level JSON field names of struct type t to
+// their field indexes.
+func jsonFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}
@@ -0,0 +1,230 @@
+package externalapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCountries_RejectsInvalidRecords(t *testing.T) {
+	body := `[
+		{"name": "France", "capital": "Paris", "population": 67000000, "currencies": []},
+		{"name": "", "capital": "Nowhere", "population": 1, "currencies": []},
+		{"name": "Bigland", "capital": "Big", "population": 9000000000, "currencies": []},
+		{"name": "Oddland", "capital": "Odd", "population": 1, "alpha3Code": "ODDL", "currencies": []}
+	]`
+
+	countries, err := decodeCountries(strings.NewReader(body), apiCountriesSchema, nil)
+
+	require.NoError(t, err)
+	require.Len(t, countries, 1)
+	assert.Equal(t, "France", countries[0].Name)
+}
+
+func TestDecodeCountries_TypeChangeRejectsRecord(t *testing.T) {
+	body := `[
+		{"name": "France", "capital": ["Paris"], "population": 67000000},
+		{"name": "Spain", "capital": "Madrid", "population": 47000000}
+	]`
+
+	countries, err := decodeCountries(strings.NewReader(body), apiCountriesSchema, nil)
+
+	require.NoError(t, err)
+	require.Len(t, countries, 1)
+	assert.Equal(t, "Spain", countries[0].Name)
+}
+
+func TestDecodeCountries_NonObjectRecordsAreRejected(t *testing.T) {
+	rejected := statValue("invalid.record")
+
+	countries, err := decodeCountries(strings.NewReader(`[1, [2, {"name": "Nested"}], null, {"name": "Spain", "capital": "Madrid"}]`), apiCountriesSchema, nil)
+
+	require.NoError(t, err)
+	require.Len(t, countries, 1)
+	assert.Equal(t, "Spain", countries[0].Name)
+	assert.Equal(t, rejected+3, statValue("invalid.record"))
+}
+
+func TestDecodeCountries_AllRejectedIsError(t *testing.T) {
+	_, err := decodeCountries(strings.NewReader(`[{"name": "France", "capital": ["Paris"]}]`), apiCountriesSchema, nil)
+
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+}
+
+func TestDecodeCountries_EmptyArrayIsNotAnError(t *testing.T) {
+	countries, err := decodeCountries(strings.NewReader(`[]`), apiCountriesSchema, nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, countries)
+}
+
+func TestDecodeCountries_CountsDrift(t *testing.T) {
+	rejected := statValue("rejected")
+	renamed := statValue("unknown.currency")
+	missing := statValue("missing.currencies")
+
+	_, err := decodeCountries(strings.NewReader(`[
+		{"name": "France", "capital": "Paris", "population": 1, "currency": [{"code": "EUR"}]},
+		{"name": "Spain", "capital": 7, "population": 1, "currency": [{"code": "EUR"}]}
+	]`), apiCountriesSchema, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, rejected+1, statValue("rejected"))
+	assert.Equal(t, renamed+2, statValue("unknown.currency"))
+	assert.Equal(t, missing+1, statValue("missing.currencies"))
+}
+
+func TestDecodeCountries_LogsEachDriftOnce(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	decode := func(field string) {
+		_, err := decodeCountries(strings.NewReader(`[{"name": "France", "capital": "Paris", "population": 1, "currencies": [], "`+field+`": 1}]`), apiCountriesSchema, nil)
+		require.NoError(t, err)
+	}
+
+	decode("logOnceA")
+	decode("logOnceA")
+	assert.Equal(t, 1, strings.Count(logs.String(), "upstream schema drift"))
+
+	decode("logOnceB")
+	assert.Equal(t, 2, strings.Count(logs.String(), "upstream schema drift"))
+}
+
+func TestDriftReport_String(t *testing.T) {
+	d := newDriftReport()
+	assert.True(t, d.Empty())
+
+	d.Records = 3
+	d.reject("population")
+	d.Unknown["currency"] = 3
+	d.Missing = []string{"currencies"}
+
+	assert.False(t, d.Empty())
+	assert.Equal(t,
+		"rejected 1 of 3 records map[population:1]; unknown fields map[currency:3]; missing fields [currencies]",
+		d.String())
+}
+
+func TestCheckCountry(t *testing.T) {
+	tests := []struct {
+		name    string
+		country Country
+		field   string
+	}{
+		{"valid", Country{Name: "Chile", Population: 19000000, Alpha2Code: "CL", Alpha3Code: "CHL", LatLng: []float64{-30, -71}}, ""},
+		{"no name", Country{Name: "  "}, "name"},
+		{"negative population", Country{Name: "X", Population: -1}, "population"},
+		{"negative area", Country{Name: "X", Area: -5}, "area"},
+		{"alpha-2", Country{Name: "X", Alpha2Code: "CHL"}, "alpha2_code"},
+		{"alpha-3", Country{Name: "X", Alpha3Code: "CL"}, "alpha3_code"},
+		{"coordinates", Country{Name: "X", LatLng: []float64{91, 0}}, "latlng"},
+		{"half coordinates", Country{Name: "X", LatLng: []float64{10}}, "latlng"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, err := checkCountry(tt.country)
+			assert.Equal(t, tt.field, field)
+			assert.Equal(t, tt.field != "", err != nil)
+		})
+	}
+}
+
+func TestAPICountriesProvider_InvalidResponseIsNotServed(t *testing.T) {
+	p := NewAPICountriesProvider("", mockClient(200, `[{"name": "France", "population": "lots"}]`))
+
+	_, err := p.SearchCountries(context.Background(), "France")
+
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+}
+
+func statValue(key string) int64 {
+	return mapValue(schemaStats, key)
+}
+
+func mapValue(m *expvar.Map, key string) int64 {
+	v, ok := m.Get(key).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return v.Value()
+}
+
+// largeResponse builds an upstream array of n full-sized records.
+func largeResponse(n int) []byte {
+	var b strings.Builder
+	b.WriteString("[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"name": "Country %d", "alpha2Code": "C%d", "alpha3Code": "C%02d", "capital": "Capital",
+			"population": %d, "area": 1000.5, "latlng": [10, 20], "timezones": ["UTC+01:00"],
+			"borders": ["AAA", "BBB", "CCC"], "altSpellings": ["Alt one", "Alt two"],
+			"currencies": [{"code": "EUR", "name": "Euro", "symbol": "€"}],
+			"languages": [{"iso639_1": "fr", "iso639_2": "fra", "name": "French", "nativeName": "français"}],
+			"flags": {"svg": "https://flagcdn.com/x.svg", "png": "https://flagcdn.com/w320/x.png"}}`,
+			i, i%10, i%100, i*1000)
+	}
+	b.WriteString("]")
+	return []byte(b.String())
+}
+
+func TestDecodeCountries_StopsEarly(t *testing.T) {
+	countries, err := decodeCountries(bytes.NewReader(largeResponse(100)), apiCountriesSchema, func(c Country) bool {
+		return c.Name == "Country 3"
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, countries, 4)
+}
+
+// BenchmarkDecode_ReadAll is the old approach of buffering the whole body
+// before unmarshalling it, for comparison.
+func BenchmarkDecode_ReadAll(b *testing.B) {
+	body := largeResponse(5000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for b.Loop() {
+		data, _ := io.ReadAll(bytes.NewReader(body))
+		var countries []CountryAPIResponse
+		if err := json.Unmarshal(data, &countries); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode_Stream(b *testing.B) {
+	body := largeResponse(5000)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for b.Loop() {
+		if _, err := decodeCountries(bytes.NewReader(body), apiCountriesSchema, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode_StreamFirstMatch(b *testing.B) {
+	body := largeResponse(5000)
+	stop := func(c Country) bool { return c.Name == "Country 10" }
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for b.Loop() {
+		if _, err := decodeCountries(bytes.NewReader(body), apiCountriesSchema, stop); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package fieldset trims JSON responses down to the fields a client asks
+// for, as a sparse fieldset such as "name,capital,currencies.code".
+package fieldset
+
+import (
+	"CountrySearch/internal/jsontree"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Set is a parsed fieldset, keyed by JSON field name. A field mapped to
+// nil is kept whole; otherwise only its own listed fields are. Fields of
+// an array's elements are listed on the array. A nil Set keeps everything.
+type Set map[string]Set
+
+// Parse reads a comma-separated list of dotted field paths and checks
+// each against the JSON fields of record, a struct type. An empty list
+// parses to a nil Set.
+func Parse(list string, record reflect.Type) (Set, error) {
+	var s Set
+	for _, path := range strings.Split(list, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		names := strings.Split(path, ".")
+		if err := check(names, record); err != nil {
+			return nil, fmt.Errorf("%w in %q", err, path)
+		}
+		if s == nil {
+			s = Set{}
+		}
+		s.add(names)
+	}
+	return s, nil
+}
+
+// check walks names down t's JSON fields.
+func check(names []string, t reflect.Type) error {
+	for _, name := range names {
+		t = elem(t)
+		if t.Kind() != reflect.Struct {
+			return fmt.Errorf("field %q has no fields", name)
+		}
+		f, ok := jsonField(t, name)
+		if !ok {
+			return fmt.Errorf("unknown field %q", name)
+		}
+		t = f.Type
+	}
+	return nil
+}
+
+// elem unwraps pointers, slices and arrays down to what they hold.
+func elem(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		if tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func (s Set) add(names []string) {
+	sub, listed := s[names[0]]
+	switch {
+	case len(names) == 1:
+		s[names[0]] = nil
+	case listed && sub == nil:
+		// Already kept whole.
+	default:
+		if sub == nil {
+			sub = Set{}
+			s[names[0]] = sub
+		}
+		sub.add(names[1:])
+	}
+}
+
+// String lists the set's paths in a canonical order, so equal sets asked
+// for differently format the same.
+func (s Set) String() string {
+	return strings.Join(s.paths(""), ",")
+}
+
+func (s Set) paths(prefix string) []string {
+	var paths []string
+	for name, sub := range s {
+		if sub == nil {
+			paths = append(paths, prefix+name)
+		} else {
+			paths = append(paths, sub.paths(prefix+name+".")...)
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// Project marshals v and trims the records found at the path of field
+// names at, or v itself when at is empty, to the fields in s. Arrays along
+// the path are walked through. Fields keep the order v marshals them in.
+func (s Set) Project(v any, at ...string) (json.RawMessage, error) {
+	raw, err := json.Marshal(v)
+	if err != nil || s == nil {
+		return raw, err
+	}
+
+	tree, err := jsontree.Decode(raw)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s.projectAt(tree, at))
+}
+
+func (s Set) projectAt(node any, at []string) any {
+	switch n := node.(type) {
+	case []any:
+		for i := range n {
+			n[i] = s.projectAt(n[i], at)
+		}
+		return n
+	case jsontree.Object:
+		if len(at) == 0 {
+			return s.filter(n)
+		}
+		for i := range n {
+			if n[i].Key == at[0] {
+				n[i].Value = s.projectAt(n[i].Value, at[1:])
+			}
+		}
+		return n
+	}
+	return node
+}
+
+func (s Set) filter(node any) any {
+	switch n := node.(type) {
+	case []any:
+		for i := range n {
+			n[i] = s.filter(n[i])
+		}
+		return n
+	case jsontree.Object:
+		kept := jsontree.Object{}
+		for _, m := range n {
+			sub, ok := s[m.Key]
+			if !ok {
+				continue
+			}
+			if sub != nil {
+				m.Value = sub.filter(m.Value)
+			}
+			kept = append(kept, m)
+		}
+		return kept
+	}
+	return node
+}
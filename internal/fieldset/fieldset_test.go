@@ -0,0 +1,97 @@
+package fieldset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type currency struct {
+	Code   string `json:"code"`
+	Symbol string `json:"symbol"`
+}
+
+type record struct {
+	Name       string     `json:"name"`
+	Capital    string     `json:"capital"`
+	Secret     string     `json:"-"`
+	Currencies []currency `json:"currencies,omitempty"`
+	Flag       *struct {
+		SVG string `json:"svg"`
+		PNG string `json:"png"`
+	} `json:"flag,omitempty"`
+}
+
+var recordType = reflect.TypeFor[record]()
+
+func TestParse(t *testing.T) {
+	s, err := Parse(" name, currencies.code ,flag.svg,", recordType)
+	require.NoError(t, err)
+	assert.Equal(t, Set{"name": nil, "currencies": Set{"code": nil}, "flag": Set{"svg": nil}}, s)
+	assert.Equal(t, "currencies.code,flag.svg,name", s.String())
+
+	s, err = Parse("", recordType)
+	require.NoError(t, err)
+	assert.Nil(t, s)
+}
+
+func TestParse_WholeFieldWins(t *testing.T) {
+	a, err := Parse("currencies.code,currencies", recordType)
+	require.NoError(t, err)
+	b, err := Parse("currencies,currencies.code", recordType)
+	require.NoError(t, err)
+
+	assert.Equal(t, Set{"currencies": nil}, a)
+	assert.Equal(t, a, b)
+}
+
+func TestParse_Rejects(t *testing.T) {
+	for list, want := range map[string]string{
+		"name,population":  `unknown field "population" in "population"`,
+		"currencies.rate":  `unknown field "rate" in "currencies.rate"`,
+		"name.first":       `field "first" has no fields in "name.first"`,
+		"Secret":           `unknown field "Secret" in "Secret"`,
+		"currencies..code": `unknown field "" in "currencies..code"`,
+	} {
+		_, err := Parse(list, recordType)
+		assert.EqualError(t, err, want, list)
+	}
+}
+
+func TestProject(t *testing.T) {
+	r := record{
+		Name:       "Panama",
+		Capital:    "Panama City",
+		Currencies: []currency{{Code: "PAB", Symbol: "B/."}, {Code: "USD", Symbol: "$"}},
+	}
+	s, err := Parse("currencies.code,name", recordType)
+	require.NoError(t, err)
+
+	raw, err := s.Project(r)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"Panama","currencies":[{"code":"PAB"},{"code":"USD"}]}`, string(raw), "fields keep their record order")
+
+	raw, err = Set(nil).Project(r)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Panama","capital":"Panama City","currencies":[{"code":"PAB","symbol":"B/."},{"code":"USD","symbol":"$"}]}`, string(raw))
+}
+
+func TestProject_At(t *testing.T) {
+	type result struct {
+		Query   string  `json:"query"`
+		Country *record `json:"country,omitempty"`
+	}
+	page := struct {
+		Results []result `json:"results"`
+		Total   int      `json:"total"`
+	}{
+		Results: []result{{Query: "pa", Country: &record{Name: "Panama", Capital: "Panama City"}}, {Query: "xx"}},
+		Total:   2,
+	}
+
+	raw, err := Set{"capital": nil}.Project(page, "results", "country")
+	require.NoError(t, err)
+	assert.Equal(t, `{"results":[{"query":"pa","country":{"capital":"Panama City"}},{"query":"xx"}],"total":2}`, string(raw))
+}
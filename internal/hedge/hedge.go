@@ -0,0 +1,229 @@
+// Package hedge issues a backup request when the first one is slower than
+// usual, keeping whichever answers first.
+package hedge
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	Percentile float64       // latency percentile (0-1) after which to hedge
+	MinDelay   time.Duration // never hedge sooner than this
+	MaxDelay   time.Duration // hedge after this long, whatever the percentile
+	MaxRate    float64       // largest fraction (0-1) of recent requests that may be hedged
+	Window     int           // recent requests the percentile and rate are measured over
+	MinSamples int           // samples needed before the percentile is trusted; until then MaxDelay applies
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Percentile: 0.95,
+		MinDelay:   50 * time.Millisecond,
+		MaxDelay:   time.Second,
+		MaxRate:    0.1,
+		Window:     200,
+		MinSamples: 20,
+	}
+}
+
+// Snapshot reports the current hedge delay and how often hedging has
+// fired and which attempt won.
+type Snapshot struct {
+	Delay       string  `json:"delay"`
+	Samples     int     `json:"samples"`
+	Requests    int     `json:"requests"`
+	Hedged      int     `json:"hedged"`
+	HedgeRate   float64 `json:"hedge_rate"`
+	BackupWins  int     `json:"backup_wins"`
+	PrimaryWins int     `json:"primary_wins"`
+	RateLimited int     `json:"rate_limited"`
+}
+
+// Policy decides when to hedge from the latencies it has observed.
+type Policy struct {
+	cfg Config
+
+	mu        sync.Mutex
+	latencies []time.Duration // ring of recent answer latencies
+	hedged    []bool          // ring of recent hedge decisions
+	next      int
+	requests  int // requests recorded, capped at the window size
+
+	backupWins, primaryWins, rateLimited int
+}
+
+func New(cfg Config) *Policy {
+	def := DefaultConfig()
+	if cfg.Window <= 0 {
+		cfg.Window = def.Window
+	}
+	if cfg.Percentile <= 0 || cfg.Percentile > 1 {
+		cfg.Percentile = def.Percentile
+	}
+	if cfg.MaxDelay < cfg.MinDelay {
+		cfg.MaxDelay = cfg.MinDelay
+	}
+
+	return &Policy{
+		cfg:       cfg,
+		latencies: make([]time.Duration, 0, cfg.Window),
+		hedged:    make([]bool, cfg.Window),
+	}
+}
+
+// Delay is how long to wait for the primary before hedging.
+func (p *Policy) Delay() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.delayLocked()
+}
+
+func (p *Policy) delayLocked() time.Duration {
+	if len(p.latencies) < p.cfg.MinSamples || len(p.latencies) == 0 {
+		return p.cfg.MaxDelay
+	}
+
+	sorted := slices.Clone(p.latencies)
+	slices.Sort(sorted)
+	i := int(math.Ceil(p.cfg.Percentile*float64(len(sorted)))) - 1
+	return min(max(sorted[max(i, 0)], p.cfg.MinDelay), p.cfg.MaxDelay)
+}
+
+// record notes one request: how long its answer took and whether it was
+// hedged. Latency is skipped when zero.
+func (p *Policy) record(latency time.Duration, hedged bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if latency > 0 {
+		if len(p.latencies) < p.cfg.Window {
+			p.latencies = append(p.latencies, latency)
+		} else {
+			p.latencies[p.next] = latency
+		}
+	}
+	p.hedged[p.next] = hedged
+	p.next = (p.next + 1) % p.cfg.Window
+	p.requests = min(p.requests+1, p.cfg.Window)
+}
+
+// allowHedge reports whether another hedge keeps the rate under MaxRate.
+func (p *Policy) allowHedge() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	recent := 0
+	for _, h := range p.hedged[:p.requests] {
+		if h {
+			recent++
+		}
+	}
+	if float64(recent+1) > p.cfg.MaxRate*float64(p.requests+1) {
+		p.rateLimited++
+		return false
+	}
+	return true
+}
+
+func (p *Policy) won(backup bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if backup {
+		p.backupWins++
+	} else {
+		p.primaryWins++
+	}
+}
+
+func (p *Policy) Snapshot() Snapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	recent := 0
+	for _, h := range p.hedged[:p.requests] {
+		if h {
+			recent++
+		}
+	}
+	snap := Snapshot{
+		Delay:       p.delayLocked().String(),
+		Samples:     len(p.latencies),
+		Requests:    p.requests,
+		Hedged:      recent,
+		BackupWins:  p.backupWins,
+		PrimaryWins: p.primaryWins,
+		RateLimited: p.rateLimited,
+	}
+	if p.requests > 0 {
+		snap.HedgeRate = float64(recent) / float64(p.requests)
+	}
+	return snap
+}
+
+type result[T any] struct {
+	value  T
+	err    error
+	backup bool
+}
+
+// Do calls primary and, if it has not answered within the policy's delay
+// and the hedge rate allows, backup as well. The first answer wins and the
+// other call is cancelled. final reports whether an error is an answer in
+// its own right, like "not found", rather than a failure worth waiting out.
+func Do[T any](ctx context.Context, p *Policy, primary, backup func(context.Context) (T, error), final func(error) bool) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	results := make(chan result[T], 2)
+	call := func(fn func(context.Context) (T, error), isBackup bool) {
+		value, err := fn(ctx)
+		results <- result[T]{value: value, err: err, backup: isBackup}
+	}
+	go call(primary, false)
+
+	timer := time.NewTimer(p.Delay())
+	defer timer.Stop()
+
+	pending, hedged := 1, false
+	var failed *result[T]
+	for {
+		select {
+		case <-timer.C:
+			if p.allowHedge() {
+				hedged = true
+				pending++
+				go call(backup, true)
+			}
+		case r := <-results:
+			pending--
+			if r.err != nil && !final(r.err) && pending > 0 {
+				// The other call may still succeed.
+				failed = &r
+				continue
+			}
+
+			if r.err == nil {
+				p.record(time.Since(start), hedged)
+			} else {
+				p.record(0, hedged)
+			}
+			if hedged && r.err == nil {
+				p.won(r.backup)
+			}
+			if r.err != nil && !final(r.err) && failed != nil {
+				// Both failed; the first failure is the more telling.
+				return failed.value, failed.err
+			}
+			return r.value, r.err
+		case <-ctx.Done():
+			p.record(0, hedged)
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package hedge
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNotFound = errors.New("not found")
+
+func isNotFound(err error) bool { return errors.Is(err, errNotFound) }
+
+// after returns a call that answers v after d, or gives up when cancelled.
+func after(d time.Duration, v string, err error, cancelled *atomic.Bool) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		select {
+		case <-time.After(d):
+			return v, err
+		case <-ctx.Done():
+			if cancelled != nil {
+				cancelled.Store(true)
+			}
+			return "", ctx.Err()
+		}
+	}
+}
+
+// eager allows hedging from the first request after a fixed delay.
+func eager(delay time.Duration) *Policy {
+	return New(Config{MinDelay: delay, MaxDelay: delay, MaxRate: 1, Window: 10, MinSamples: 100})
+}
+
+func TestDo_FastPrimaryIsNotHedged(t *testing.T) {
+	p := eager(50 * time.Millisecond)
+	var backupCalled atomic.Bool
+	backup := func(ctx context.Context) (string, error) {
+		backupCalled.Store(true)
+		return "backup", nil
+	}
+
+	v, err := Do(context.Background(), p, after(time.Millisecond, "primary", nil, nil), backup, isNotFound)
+
+	require.NoError(t, err)
+	assert.Equal(t, "primary", v)
+	assert.False(t, backupCalled.Load())
+	assert.Equal(t, 0, p.Snapshot().Hedged)
+}
+
+func TestDo_SlowPrimaryLosesToBackup(t *testing.T) {
+	p := eager(10 * time.Millisecond)
+	var cancelled atomic.Bool
+
+	v, err := Do(context.Background(), p,
+		after(time.Second, "primary", nil, &cancelled),
+		after(time.Millisecond, "backup", nil, nil),
+		isNotFound)
+
+	require.NoError(t, err)
+	assert.Equal(t, "backup", v)
+	assert.Eventually(t, cancelled.Load, time.Second, time.Millisecond, "the loser is cancelled")
+	snap := p.Snapshot()
+	assert.Equal(t, 1, snap.Hedged)
+	assert.Equal(t, 1, snap.BackupWins)
+}
+
+func TestDo_PrimaryCanStillWinAfterHedging(t *testing.T) {
+	p := eager(10 * time.Millisecond)
+
+	v, err := Do(context.Background(), p,
+		after(20*time.Millisecond, "primary", nil, nil),
+		after(time.Second, "backup", nil, nil),
+		isNotFound)
+
+	require.NoError(t, err)
+	assert.Equal(t, "primary", v)
+	assert.Equal(t, 1, p.Snapshot().PrimaryWins)
+}
+
+func TestDo_FailedCallWaitsForTheOther(t *testing.T) {
+	p := eager(10 * time.Millisecond)
+
+	v, err := Do(context.Background(), p,
+		after(20*time.Millisecond, "", errors.New("boom"), nil),
+		after(40*time.Millisecond, "backup", nil, nil),
+		isNotFound)
+
+	require.NoError(t, err)
+	assert.Equal(t, "backup", v)
+}
+
+func TestDo_FinalErrorIsAnAnswer(t *testing.T) {
+	p := eager(10 * time.Millisecond)
+
+	_, err := Do(context.Background(), p,
+		after(20*time.Millisecond, "", errNotFound, nil),
+		after(time.Second, "backup", nil, nil),
+		isNotFound)
+
+	assert.ErrorIs(t, err, errNotFound)
+}
+
+func TestDo_BothFailReturnsFirstFailure(t *testing.T) {
+	p := eager(10 * time.Millisecond)
+	first := errors.New("first")
+
+	_, err := Do(context.Background(), p,
+		after(20*time.Millisecond, "", first, nil),
+		after(30*time.Millisecond, "", errors.New("second"), nil),
+		isNotFound)
+
+	assert.ErrorIs(t, err, first)
+}
+
+func TestDo_EarlyFailureIsNotHedged(t *testing.T) {
+	p := eager(50 * time.Millisecond)
+	var backupCalled atomic.Bool
+
+	_, err := Do(context.Background(), p,
+		after(time.Millisecond, "", errors.New("boom"), nil),
+		func(ctx context.Context) (string, error) {
+			backupCalled.Store(true)
+			return "backup", nil
+		},
+		isNotFound)
+
+	assert.Error(t, err)
+	assert.False(t, backupCalled.Load())
+}
+
+func TestDo_CallerCancellation(t *testing.T) {
+	p := eager(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := Do(ctx, p, after(time.Second, "primary", nil, nil), after(time.Second, "backup", nil, nil), isNotFound)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPolicy_DelayFollowsPercentile(t *testing.T) {
+	p := New(Config{Percentile: 0.9, MinDelay: time.Millisecond, MaxDelay: time.Second, MaxRate: 0.1, Window: 10, MinSamples: 10})
+	assert.Equal(t, time.Second, p.Delay(), "max delay until there are enough samples")
+
+	for i := 1; i <= 10; i++ {
+		p.record(time.Duration(i)*10*time.Millisecond, false)
+	}
+	assert.Equal(t, 90*time.Millisecond, p.Delay())
+
+	for i := 0; i < 10; i++ {
+		p.record(2*time.Second, false)
+	}
+	assert.Equal(t, time.Second, p.Delay(), "clamped to the max delay")
+}
+
+func TestPolicy_CapsHedgeRate(t *testing.T) {
+	p := New(Config{MinDelay: time.Millisecond, MaxDelay: time.Millisecond, MaxRate: 0.2, Window: 10})
+
+	for i := 0; i < 4; i++ {
+		p.record(time.Millisecond, false)
+	}
+	require.True(t, p.allowHedge(), "1 in 5")
+	p.record(time.Millisecond, true)
+	assert.False(t, p.allowHedge(), "2 in 6 is over the cap")
+
+	snap := p.Snapshot()
+	assert.Equal(t, 5, snap.Requests)
+	assert.Equal(t, 1, snap.Hedged)
+	assert.Equal(t, 1, snap.RateLimited)
+	assert.InDelta(t, 0.2, snap.HedgeRate, 0.001)
+}
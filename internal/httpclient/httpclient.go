@@ -0,0 +1,145 @@
+// Package httpclient builds the HTTP client used for every outbound
+// upstream call, with timeouts, connection pooling, proxy and TLS settings
+// taken from config.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultUserAgent identifies the service to upstream operators.
+const DefaultUserAgent = "CountrySearchAPI/1.0 (+https://github.com/imrahul361/CountrySearchAPI)"
+
+type Config struct {
+	// Timeout bounds a whole request, including reading the body.
+	Timeout               time.Duration
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps connections per host, 0 is unlimited.
+	MaxConnsPerHost int
+
+	// Proxy is the HTTP proxy URL. Empty uses HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY from the environment.
+	Proxy string
+	// CABundle is a PEM file of extra certificate authorities to trust on
+	// top of the system pool.
+	CABundle  string
+	UserAgent string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:               10 * time.Second,
+		DialTimeout:           5 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 5 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		UserAgent:             DefaultUserAgent,
+	}
+}
+
+// New builds a client from cfg.
+func New(cfg Config) (*http.Client, error) {
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: cfg.Timeout}, nil
+}
+
+// NewTransport builds the client's transport from cfg, for callers that
+// wrap it in their own RoundTripper.
+func NewTransport(cfg Config) (http.RoundTripper, error) {
+	proxy := http.ProxyFromEnvironment
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", cfg.Proxy)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CABundle != "" {
+		pool, err := loadCABundle(cfg.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		ForceAttemptHTTP2:     true,
+	}
+
+	if cfg.UserAgent == "" {
+		return transport, nil
+	}
+	return &userAgentTransport{next: transport, userAgent: cfg.UserAgent}, nil
+}
+
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
+
+// userAgentTransport sets the User-Agent on requests that have none.
+type userAgentTransport struct {
+	next      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// Default returns a shared client built from DefaultConfig, for callers
+// given no client of their own.
+var Default = sync.OnceValue(func() *http.Client {
+	client, err := New(DefaultConfig())
+	if err != nil {
+		log.Fatalf("error building default HTTP client: %v", err)
+	}
+	return client
+})
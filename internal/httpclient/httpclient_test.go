@@ -0,0 +1,132 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func echoUserAgent() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Seen-User-Agent", r.UserAgent())
+		w.Header().Set("X-Seen-URL", r.URL.String())
+	}))
+}
+
+func TestNew_SetsUserAgent(t *testing.T) {
+	srv := echoUserAgent()
+	defer srv.Close()
+	client, err := New(DefaultConfig())
+	require.NoError(t, err)
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, DefaultUserAgent, resp.Header.Get("X-Seen-User-Agent"))
+	assert.Equal(t, 10*time.Second, client.Timeout)
+}
+
+func TestNew_KeepsCallerUserAgent(t *testing.T) {
+	srv := echoUserAgent()
+	defer srv.Close()
+	client, err := New(DefaultConfig())
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "custom/2.0")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "custom/2.0", resp.Header.Get("X-Seen-User-Agent"))
+}
+
+func TestNew_RoutesThroughProxy(t *testing.T) {
+	proxy := echoUserAgent()
+	defer proxy.Close()
+	cfg := DefaultConfig()
+	cfg.Proxy = proxy.URL
+	client, err := New(cfg)
+	require.NoError(t, err)
+
+	resp, err := client.Get("http://upstream.invalid/countries")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "http://upstream.invalid/countries", resp.Header.Get("X-Seen-URL"))
+}
+
+func TestNew_InvalidProxy(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Proxy = "not a url"
+
+	_, err := New(cfg)
+
+	assert.ErrorContains(t, err, "invalid proxy URL")
+}
+
+func TestNew_TrustsCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client, err := New(DefaultConfig())
+	require.NoError(t, err)
+	_, err = client.Get(srv.URL)
+	require.Error(t, err, "the test server's certificate is self-signed")
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(bundle, certPEM, 0o600))
+
+	cfg := DefaultConfig()
+	cfg.CABundle = bundle
+	client, err = New(cfg)
+	require.NoError(t, err)
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestNew_BadCABundle(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CABundle = filepath.Join(t.TempDir(), "missing.pem")
+	_, err := New(cfg)
+	assert.Error(t, err)
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("nothing here"), 0o600))
+	cfg.CABundle = empty
+	_, err = New(cfg)
+	assert.ErrorContains(t, err, "no certificates")
+}
+
+func TestNew_ResponseHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	cfg := DefaultConfig()
+	cfg.ResponseHeaderTimeout = 20 * time.Millisecond
+	client, err := New(cfg)
+	require.NoError(t, err)
+
+	_, err = client.Get(srv.URL)
+
+	assert.ErrorContains(t, err, "timeout awaiting response headers")
+}
+
+func TestDefault_IsShared(t *testing.T) {
+	assert.Same(t, Default(), Default())
+}
@@ -0,0 +1,233 @@
+// Package httpfixture records HTTP exchanges to fixture files and replays
+// them, so tests and local development can run against upstream responses
+// without a network.
+//
+// Each exchange is one JSON file in a directory. Requests are matched on
+// method, path and query; the host is ignored so fixtures recorded against
+// one base URL replay under any other.
+package httpfixture
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrNoFixture means a replayed request matched no recorded exchange.
+	ErrNoFixture = errors.New("no fixture for request")
+	// ErrBodyTooLarge means a response was too big to record.
+	ErrBodyTooLarge = errors.New("response body too large to record")
+)
+
+// DefaultMaxBodySize bounds the response bodies a Recorder reads.
+const DefaultMaxBodySize = 8 << 20
+
+// Exchange is a recorded request and its response.
+type Exchange struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+}
+
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	// Body holds JSON bodies as is, so fixtures stay readable and diffable.
+	// Anything else goes in BodyText.
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodyText string          `json:"body_text,omitempty"`
+}
+
+func requestOf(r *http.Request) Request {
+	return Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query().Encode(),
+	}
+}
+
+func (r Request) key() string {
+	return r.Method + " " + r.Path + "?" + r.Query
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileName derives a readable, stable file name for r.
+func (r Request) fileName() string {
+	name := r.Method + "_" + strings.Trim(unsafeChars.ReplaceAllString(r.Path, "_"), "_")
+	if r.Query != "" {
+		sum := sha256.Sum256([]byte(r.Query))
+		name += "_" + hex.EncodeToString(sum[:4])
+	}
+	return name + ".json"
+}
+
+// Recorder is an http.RoundTripper that passes requests on to Transport
+// and saves every exchange in Dir, replacing any earlier recording of the
+// same request. Responses over MaxBodySize fail instead of being recorded.
+type Recorder struct {
+	Dir         string
+	Transport   http.RoundTripper
+	MaxBodySize int64
+
+	mu sync.Mutex
+}
+
+func NewRecorder(dir string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{Dir: dir, Transport: transport, MaxBodySize: DefaultMaxBodySize}
+}
+
+func (rec *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rec.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, rec.MaxBodySize+1))
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > rec.MaxBodySize {
+		return nil, fmt.Errorf("%w: over %d bytes from %s", ErrBodyTooLarge, rec.MaxBodySize, req.URL.Path)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	exchange := Exchange{
+		Request: requestOf(req),
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: recordedHeader(resp.Header),
+		},
+	}
+	if json.Valid(body) {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err != nil {
+			return nil, err
+		}
+		exchange.Response.Body = indented.Bytes()
+	} else {
+		exchange.Response.BodyText = string(body)
+	}
+
+	if err := rec.save(exchange); err != nil {
+		return nil, fmt.Errorf("error recording fixture: %w", err)
+	}
+	return resp, nil
+}
+
+func (rec *Recorder) save(exchange Exchange) error {
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if err := os.MkdirAll(rec.Dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(rec.Dir, exchange.Request.fileName()), append(data, '\n'), 0o644)
+}
+
+// recordedHeader keeps the headers that affect how a response is handled
+// and drops volatile ones like Date and cookies.
+func recordedHeader(h http.Header) http.Header {
+	kept := make(http.Header)
+	for _, name := range []string{"Content-Type", "Cache-Control", "ETag", "Last-Modified", "Retry-After"} {
+		for _, v := range h.Values(name) {
+			kept.Add(name, v)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// Replayer is an http.RoundTripper that answers from recorded exchanges
+// and never touches the network.
+type Replayer struct {
+	exchanges map[string]Exchange
+}
+
+// NewReplayer loads every fixture in dir.
+func NewReplayer(dir string) (*Replayer, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no fixtures in %s", dir)
+	}
+
+	rep := &Replayer{exchanges: make(map[string]Exchange, len(paths))}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var exchange Exchange
+		if err := json.Unmarshal(data, &exchange); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		rep.exchanges[exchange.Request.key()] = exchange
+	}
+	return rep, nil
+}
+
+// Len returns the number of recorded exchanges.
+func (rep *Replayer) Len() int {
+	return len(rep.exchanges)
+}
+
+func (rep *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	exchange, ok := rep.exchanges[requestOf(req).key()]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoFixture, req.Method, req.URL.RequestURI())
+	}
+
+	body := []byte(exchange.Response.BodyText)
+	if len(exchange.Response.Body) > 0 {
+		body = exchange.Response.Body
+	}
+	header := exchange.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Response.Status, http.StatusText(exchange.Response.Status)),
+		StatusCode:    exchange.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
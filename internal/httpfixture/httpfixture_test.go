@@ -0,0 +1,133 @@
+package httpfixture
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func upstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/name/France":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`[{"name":"France","capital":"Paris"}]`))
+		case "/search":
+			_, _ = w.Write([]byte("query " + r.URL.Query().Get("q")))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, client *http.Client, url string) (*http.Response, string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	srv := upstream(t)
+	dir := t.TempDir()
+
+	recording := &http.Client{Transport: NewRecorder(dir, nil)}
+	resp, body := get(t, recording, srv.URL+"/name/France")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `[{"name":"France","capital":"Paris"}]`, body, "recording passes the body through")
+	get(t, recording, srv.URL+"/search?q=chad")
+	get(t, recording, srv.URL+"/name/Atlantis")
+
+	rep, err := NewReplayer(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, rep.Len())
+	replaying := &http.Client{Transport: rep}
+
+	resp, body = get(t, replaying, "http://elsewhere.test/name/France")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `"v1"`, resp.Header.Get("ETag"))
+	assert.JSONEq(t, `[{"name":"France","capital":"Paris"}]`, body)
+
+	_, body = get(t, replaying, "http://elsewhere.test/search?q=chad")
+	assert.Equal(t, "query chad", body)
+
+	resp, _ = get(t, replaying, "http://elsewhere.test/name/Atlantis")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestReplayer_MatchesMethodPathAndQuery(t *testing.T) {
+	srv := upstream(t)
+	dir := t.TempDir()
+	get(t, &http.Client{Transport: NewRecorder(dir, nil)}, srv.URL+"/search?q=chad")
+
+	rep, err := NewReplayer(dir)
+	require.NoError(t, err)
+	client := &http.Client{Transport: rep}
+
+	for _, url := range []string{
+		"http://x.test/search?q=chile",
+		"http://x.test/search",
+		"http://x.test/name/chad",
+	} {
+		_, err := client.Get(url)
+		assert.ErrorIs(t, err, ErrNoFixture, url)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "http://x.test/search?q=chad", nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, ErrNoFixture)
+}
+
+func TestRecorder_WritesReadableFixtures(t *testing.T) {
+	srv := upstream(t)
+	dir := t.TempDir()
+	get(t, &http.Client{Transport: NewRecorder(dir, nil)}, srv.URL+"/name/France")
+
+	data, err := os.ReadFile(filepath.Join(dir, "GET_name_France.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"name": "France"`)
+	assert.NotContains(t, string(data), "Date", "volatile headers are dropped")
+}
+
+func TestRecorder_RefusesOversizedBodies(t *testing.T) {
+	srv := upstream(t)
+	dir := t.TempDir()
+	rec := NewRecorder(dir, nil)
+	rec.MaxBodySize = 10
+
+	_, err := (&http.Client{Transport: rec}).Get(srv.URL + "/name/France")
+
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestNewReplayer_EmptyDirectory(t *testing.T) {
+	_, err := NewReplayer(t.TempDir())
+
+	assert.Error(t, err)
+}
+
+func TestRequest_FileName(t *testing.T) {
+	assert.Equal(t, "GET_name_United_States.json", Request{Method: "GET", Path: "/name/United States"}.fileName())
+	assert.Equal(t, "GET_countries.json", Request{Method: "GET", Path: "/countries"}.fileName())
+	assert.NotEqual(t,
+		Request{Method: "GET", Path: "/search", Query: "q=a"}.fileName(),
+		Request{Method: "GET", Path: "/search", Query: "q=b"}.fileName())
+}
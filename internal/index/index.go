@@ -0,0 +1,181 @@
+// Package index is an immutable in-memory index over a full country
+// dataset, keyed by name, alias and ISO 3166 code.
+package index
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/match"
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrMalformedCode means a string cannot be an ISO 3166-1 code at all, as
+// opposed to a well-formed code no country is assigned.
+var ErrMalformedCode = errors.New("malformed ISO 3166-1 code")
+
+type Index struct {
+	countries []externalapi.Country
+	byName    map[string]int // normalized name, native name or alias
+	byCode    map[string]int // upper-case alpha-2, alpha-3 or numeric code
+	names     [][]string     // normalized names per country, for Search
+
+	prefixOnce sync.Once
+	prefixes   *prefixNode // for Complete
+}
+
+// New indexes countries. Primary names win over aliases when they collide.
+func New(countries []externalapi.Country) *Index {
+	idx := &Index{
+		countries: countries,
+		byName:    make(map[string]int, len(countries)*4),
+		byCode:    make(map[string]int, len(countries)*3),
+		names:     make([][]string, len(countries)),
+	}
+
+	for i, c := range countries {
+		idx.byName[match.Normalize(c.Name)] = i
+		for _, code := range []string{c.Alpha2Code, c.Alpha3Code, c.NumericCode} {
+			if code != "" {
+				idx.byCode[strings.ToUpper(code)] = i
+			}
+		}
+		for _, n := range c.Names() {
+			idx.names[i] = append(idx.names[i], match.Normalize(n))
+		}
+	}
+	for i, names := range idx.names {
+		for _, n := range names {
+			if _, taken := idx.byName[n]; !taken && n != "" {
+				idx.byName[n] = i
+			}
+		}
+	}
+	return idx
+}
+
+// Validate checks countries is a plausible full dataset before it replaces
+// a working index.
+func Validate(countries []externalapi.Country, minCountries int) error {
+	if len(countries) < minCountries {
+		return fmt.Errorf("dataset has %d countries, want at least %d", len(countries), minCountries)
+	}
+
+	seen := make(map[string]bool, len(countries))
+	for i, c := range countries {
+		if c.Name == "" || c.Alpha3Code == "" {
+			return fmt.Errorf("country %d is missing its name or alpha-3 code", i)
+		}
+		if seen[c.Alpha3Code] {
+			return fmt.Errorf("duplicate country %s", c.Alpha3Code)
+		}
+		seen[c.Alpha3Code] = true
+	}
+	return nil
+}
+
+func (idx *Index) Len() int {
+	return len(idx.countries)
+}
+
+// All returns every country in dataset order. Callers must not modify it.
+func (idx *Index) All() []externalapi.Country {
+	return idx.countries
+}
+
+// Lookup finds a country by its name, native name or an alias.
+func (idx *Index) Lookup(name string) (externalapi.Country, bool) {
+	i, ok := idx.byName[match.Normalize(name)]
+	if !ok {
+		return externalapi.Country{}, false
+	}
+	return idx.countries[i], true
+}
+
+// ParseCode normalizes an ISO 3166-1 code: two or three letters in upper
+// case, or up to three digits padded to three.
+func ParseCode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if code == "" || len(code) > 3 {
+		return "", fmt.Errorf("%w: %q", ErrMalformedCode, code)
+	}
+
+	letters, digits := 0, 0
+	for _, r := range code {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+			letters++
+		case '0' <= r && r <= '9':
+			digits++
+		}
+	}
+	switch {
+	case letters == len(code) && len(code) >= 2:
+		return strings.ToUpper(code), nil
+	case digits == len(code):
+		return strings.Repeat("0", 3-len(code)) + code, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrMalformedCode, code)
+}
+
+// ByCode finds a country by alpha-2, alpha-3 or numeric code.
+func (idx *Index) ByCode(code string) (externalapi.Country, bool) {
+	i, ok := idx.byCode[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return externalapi.Country{}, false
+	}
+	return idx.countries[i], true
+}
+
+// Search returns every country with a name containing name, the same way
+// the upstream's name search does.
+func (idx *Index) Search(name string) []externalapi.Country {
+	query := match.Normalize(name)
+	if query == "" {
+		return nil
+	}
+
+	var found []externalapi.Country
+	for i, names := range idx.names {
+		for _, n := range names {
+			if strings.Contains(n, query) {
+				found = append(found, idx.countries[i])
+				break
+			}
+		}
+	}
+	return found
+}
+
+// Suggestion is a country a query that found nothing may have meant.
+type Suggestion struct {
+	Country    externalapi.Country
+	Matched    string // the name that was close, as the dataset spells it
+	Confidence float64
+}
+
+// Suggest returns up to limit countries with a name, native name or alias
+// that is a likely correction of query, scored by match.Confidence and
+// most confident first. Names scoring below minConfidence are left out.
+func (idx *Index) Suggest(query string, limit int, minConfidence float64) []Suggestion {
+	var found []Suggestion
+	for i, c := range idx.countries {
+		best := Suggestion{Country: c}
+		for j, name := range c.Names() {
+			if conf := match.Confidence(query, idx.names[i][j]); conf > best.Confidence {
+				best.Matched, best.Confidence = name, conf
+			}
+		}
+		if best.Confidence >= minConfidence && best.Confidence > 0 {
+			found = append(found, best)
+		}
+	}
+
+	slices.SortStableFunc(found, func(a, b Suggestion) int {
+		return cmp.Compare(b.Confidence, a.Confidence)
+	})
+	return found[:min(max(limit, 0), len(found))]
+}
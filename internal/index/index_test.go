@@ -0,0 +1,172 @@
+package index
+
+import (
+	"CountrySearch/internal/externalapi"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCountries = []externalapi.Country{
+	{Name: "Niger", Alpha2Code: "NE", Alpha3Code: "NER", NumericCode: "562", AltSpellings: []string{"NE", "Republic of Niger"}},
+	{Name: "Nigeria", Alpha2Code: "NG", Alpha3Code: "NGA", NumericCode: "566", AltSpellings: []string{"NG", "Federal Republic of Nigeria"}},
+	{Name: "Germany", NativeName: "Deutschland", Alpha2Code: "DE", Alpha3Code: "DEU", NumericCode: "276"},
+	{Name: "United States", Alpha2Code: "US", Alpha3Code: "USA", NumericCode: "840", AltSpellings: []string{"US", "USA", "United States of America"}},
+}
+
+func TestIndex_Lookup(t *testing.T) {
+	idx := New(testCountries)
+
+	tests := map[string]string{
+		"niger":                    "Niger",
+		"NIGERIA":                  "Nigeria",
+		"deutschland":              "Germany",
+		"united states of america": "United States",
+		"usa":                      "United States",
+	}
+	for query, want := range tests {
+		c, ok := idx.Lookup(query)
+		require.True(t, ok, query)
+		assert.Equal(t, want, c.Name, query)
+	}
+
+	_, ok := idx.Lookup("Nige")
+	assert.False(t, ok)
+}
+
+func TestIndex_PrimaryNameBeatsAlias(t *testing.T) {
+	idx := New([]externalapi.Country{
+		{Name: "Alpha", Alpha3Code: "AAA", AltSpellings: []string{"Beta"}},
+		{Name: "Beta", Alpha3Code: "BBB"},
+	})
+
+	c, ok := idx.Lookup("beta")
+
+	require.True(t, ok)
+	assert.Equal(t, "BBB", c.Alpha3Code)
+}
+
+func TestIndex_ByCode(t *testing.T) {
+	idx := New(testCountries)
+
+	for _, code := range []string{"de", "DEU", "276", " deu "} {
+		c, ok := idx.ByCode(code)
+		require.True(t, ok, code)
+		assert.Equal(t, "Germany", c.Name)
+	}
+
+	_, ok := idx.ByCode("XX")
+	assert.False(t, ok)
+}
+
+func TestParseCode(t *testing.T) {
+	for in, want := range map[string]string{"de": "DE", "Deu": "DEU", " 276 ": "276", "4": "004", "36": "036"} {
+		code, err := ParseCode(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, code, in)
+	}
+
+	for _, in := range []string{"", "D", "DEUT", "D1", "2a6", "1234", "dé"} {
+		_, err := ParseCode(in)
+		assert.ErrorIs(t, err, ErrMalformedCode, in)
+	}
+}
+
+func TestIndex_Search(t *testing.T) {
+	idx := New(testCountries)
+
+	found := idx.Search("niger")
+
+	require.Len(t, found, 2)
+	assert.Equal(t, "Niger", found[0].Name)
+	assert.Equal(t, "Nigeria", found[1].Name)
+	assert.Empty(t, idx.Search("  "))
+	assert.Equal(t, 4, idx.Len())
+	assert.Len(t, idx.All(), 4)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(testCountries, 4))
+	assert.ErrorContains(t, Validate(testCountries, 5), "at least 5")
+	assert.ErrorContains(t, Validate(append(testCountries, externalapi.Country{Name: "Again", Alpha3Code: "DEU"}), 1), "duplicate")
+	assert.ErrorContains(t, Validate([]externalapi.Country{{Name: "Nameless"}}, 1), "missing")
+}
+
+func TestIndex_Complete(t *testing.T) {
+	idx := New([]externalapi.Country{
+		{Name: "Germany", NativeName: "Deutschland", Alpha3Code: "DEU", Population: 83000000},
+		{Name: "Georgia", Alpha3Code: "GEO", Population: 3700000},
+		{Name: "South Korea", Alpha3Code: "KOR", Population: 51000000, AltSpellings: []string{"Korea"}},
+		{Name: "North Korea", Alpha3Code: "PRK", Population: 26000000},
+		{Name: "Algeria", Alpha3Code: "DZA", Population: 45000000},
+	})
+
+	names := func(completions []Completion) []string {
+		var out []string
+		for _, c := range completions {
+			out = append(out, c.Country.Name+"/"+c.Matched)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"Germany/Germany", "Georgia/Georgia"}, names(idx.Complete("ge", 5)))
+	assert.Equal(t, []string{"Germany/Germany"}, names(idx.Complete("GER", 5)))
+	assert.Equal(t, []string{"Germany/Deutschland"}, names(idx.Complete("deu", 5)))
+	assert.Equal(t, []string{"South Korea/South Korea", "North Korea/North Korea"}, names(idx.Complete("kor", 5)),
+		"each country appears once, under its first matching name")
+	assert.Equal(t, []string{"South Korea/South Korea"}, names(idx.Complete("kor", 1)))
+	assert.Empty(t, idx.Complete("geria", 5), "only the starts of words match")
+	assert.Empty(t, idx.Complete("", 5))
+	assert.Empty(t, idx.Complete("xyz", 5))
+}
+
+func BenchmarkIndex_Complete(b *testing.B) {
+	countries := make([]externalapi.Country, 250)
+	for i := range countries {
+		countries[i] = externalapi.Country{
+			Name:         fmt.Sprintf("Republic of Country %d", i),
+			Alpha3Code:   fmt.Sprintf("C%02d", i),
+			Population:   i * 1000,
+			AltSpellings: []string{fmt.Sprintf("Country %d", i), fmt.Sprintf("Land %d", i)},
+		}
+	}
+	idx := New(countries)
+	idx.Complete("c", 1)
+	b.ReportAllocs()
+	for b.Loop() {
+		idx.Complete("country 1", 5)
+	}
+}
+
+func TestIndex_Suggest(t *testing.T) {
+	idx := New([]externalapi.Country{
+		{Name: "Germany", NativeName: "Deutschland", Alpha3Code: "DEU"},
+		{Name: "Philippines", Alpha3Code: "PHL", AltSpellings: []string{"Pilipinas"}},
+		{Name: "Armenia", Alpha3Code: "ARM"},
+		{Name: "France", Alpha3Code: "FRA"},
+	})
+
+	found := idx.Suggest("Germny", 3, 0.6)
+	require.Len(t, found, 1)
+	assert.Equal(t, "Germany", found[0].Country.Name)
+	assert.Equal(t, "Germany", found[0].Matched)
+	assert.InDelta(t, 6.0/7.0, found[0].Confidence, 1e-9)
+
+	found = idx.Suggest("Filipines", 3, 0.6)
+	require.Len(t, found, 1)
+	assert.Equal(t, "Philippines", found[0].Country.Name)
+
+	found = idx.Suggest("Deutchland", 3, 0.6)
+	require.Len(t, found, 1)
+	assert.Equal(t, "Deutschland", found[0].Matched, "native names and aliases count")
+
+	found = idx.Suggest("Germenia", 3, 0.5)
+	require.Len(t, found, 2)
+	assert.Equal(t, "Germany", found[0].Country.Name, "sounds alike, so most confident")
+	assert.Equal(t, "Armenia", found[1].Country.Name)
+	assert.Len(t, idx.Suggest("Germenia", 1, 0.5), 1)
+
+	assert.Empty(t, idx.Suggest("Atlantis", 3, 0.6))
+}
@@ -0,0 +1,104 @@
+package index
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/match"
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// MaxCompletions is the most completions Complete returns for a prefix.
+const MaxCompletions = 20
+
+// Completion is a country whose name, or one of whose names, starts with
+// the prefix asked for.
+type Completion struct {
+	Country externalapi.Country
+	Matched string // the name that matched, as the dataset spells it
+}
+
+// completion is a Completion as the trie files it.
+type completion struct {
+	country int // position in countries
+	matched string
+}
+
+// prefixNode is one step of the prefix trie. Each node keeps its own
+// ranked completions, so a lookup is a walk down the prefix and a copy.
+type prefixNode struct {
+	children    map[rune]*prefixNode
+	completions []completion
+}
+
+// Complete returns up to limit countries with a name starting with prefix,
+// most populous first. Every word of a name counts as a start, so "kor"
+// completes to "South Korea". The trie is built on first use.
+func (idx *Index) Complete(prefix string, limit int) []Completion {
+	idx.prefixOnce.Do(idx.buildPrefixes)
+
+	node := idx.prefixes
+	for _, r := range match.Normalize(prefix) {
+		if node = node.children[r]; node == nil {
+			return nil
+		}
+	}
+	if node == idx.prefixes || limit <= 0 {
+		return nil
+	}
+	completions := make([]Completion, 0, min(limit, len(node.completions)))
+	for _, c := range node.completions[:cap(completions)] {
+		completions = append(completions, Completion{Country: idx.countries[c.country], Matched: c.matched})
+	}
+	return completions
+}
+
+func (idx *Index) buildPrefixes() {
+	idx.prefixes = &prefixNode{}
+	for i, c := range idx.countries {
+		for j, name := range c.Names() {
+			// idx.names holds the normalized form of c.Names() in order.
+			normalized := idx.names[i][j]
+			for start := 0; start < len(normalized); {
+				idx.prefixes.insert(normalized[start:], completion{country: i, matched: name})
+				next := strings.IndexByte(normalized[start:], ' ')
+				if next < 0 {
+					break
+				}
+				start += next + 1
+			}
+		}
+	}
+	idx.prefixes.rank(func(a, b completion) int {
+		ca, cb := idx.countries[a.country], idx.countries[b.country]
+		return cmp.Or(cmp.Compare(cb.Population, ca.Population), strings.Compare(ca.Name, cb.Name))
+	})
+}
+
+// insert files c under every prefix of key. A country is filed once per
+// node, under the first of its names to reach it.
+func (n *prefixNode) insert(key string, c completion) {
+	for _, r := range key {
+		child := n.children[r]
+		if child == nil {
+			if n.children == nil {
+				n.children = make(map[rune]*prefixNode)
+			}
+			child = &prefixNode{}
+			n.children[r] = child
+		}
+		n = child
+		if !slices.ContainsFunc(n.completions, func(e completion) bool { return e.country == c.country }) {
+			n.completions = append(n.completions, c)
+		}
+	}
+}
+
+// rank sorts every node's completions and keeps the best MaxCompletions.
+func (n *prefixNode) rank(compare func(a, b completion) int) {
+	slices.SortStableFunc(n.completions, compare)
+	n.completions = slices.Clip(n.completions[:min(len(n.completions), MaxCompletions)])
+	for _, child := range n.children {
+		child.rank(compare)
+	}
+}
@@ -0,0 +1,54 @@
+// Package iso4217 is an embedded copy of the ISO 4217 currency table.
+package iso4217
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"log"
+	"strconv"
+	"strings"
+)
+
+//go:embed iso4217.csv
+var tableCSV string
+
+type Currency struct {
+	Code    string
+	Numeric string
+	Name    string
+	Symbol  string
+	// MinorUnits is the number of decimal places, or -1 where ISO 4217
+	// does not define one (precious metals, testing codes).
+	MinorUnits int
+}
+
+var byCode = load()
+
+func load() map[string]Currency {
+	records, err := csv.NewReader(strings.NewReader(tableCSV)).ReadAll()
+	if err != nil {
+		log.Fatalf("error parsing embedded ISO 4217 table. Err: %v", err)
+	}
+
+	table := make(map[string]Currency, len(records))
+	for _, rec := range records[1:] {
+		minor, err := strconv.Atoi(rec[2])
+		if err != nil {
+			minor = -1
+		}
+		table[rec[0]] = Currency{
+			Code:       rec[0],
+			Numeric:    rec[1],
+			MinorUnits: minor,
+			Name:       rec[3],
+			Symbol:     rec[4],
+		}
+	}
+	return table
+}
+
+// Lookup finds a currency by its alphabetic code, ignoring case.
+func Lookup(code string) (Currency, bool) {
+	c, ok := byCode[strings.ToUpper(strings.TrimSpace(code))]
+	return c, ok
+}
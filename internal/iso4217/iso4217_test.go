@@ -0,0 +1,44 @@
+package iso4217
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	c, ok := Lookup("usd")
+
+	require.True(t, ok)
+	assert.Equal(t, Currency{Code: "USD", Numeric: "840", Name: "US Dollar", Symbol: "$", MinorUnits: 2}, c)
+}
+
+func TestLookup_MinorUnits(t *testing.T) {
+	tests := map[string]int{
+		"JPY": 0,
+		"EUR": 2,
+		"KWD": 3,
+		"CLF": 4,
+		"XAU": -1,
+	}
+
+	for code, want := range tests {
+		c, ok := Lookup(code)
+		require.True(t, ok, code)
+		assert.Equal(t, want, c.MinorUnits, code)
+	}
+}
+
+func TestLookup_Unknown(t *testing.T) {
+	_, ok := Lookup("ABC")
+	assert.False(t, ok)
+}
+
+func TestTable_CodesAreUnique(t *testing.T) {
+	assert.Greater(t, len(byCode), 170)
+	for code, c := range byCode {
+		assert.Equal(t, code, c.Code)
+		assert.Len(t, c.Numeric, 3, code)
+	}
+}
@@ -0,0 +1,89 @@
+// Package jsontree decodes JSON into plain values that keep the order of
+// object fields, for code that reshapes or re-encodes responses.
+package jsontree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Object is a JSON object with its fields in order.
+type Object []Member
+
+type Member struct {
+	Key   string
+	Value any
+}
+
+func (o Object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// Decode reads raw into an Object, []any, string, json.Number, bool or
+// nil. Numbers are kept as written.
+func Decode(raw []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return decode(dec)
+}
+
+func decode(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		o := Object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, Member{Key: key.(string), Value: value})
+		}
+		_, err = dec.Token()
+		return o, err
+	case '[':
+		a := []any{}
+		for dec.More() {
+			value, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, value)
+		}
+		_, err = dec.Token()
+		return a, err
+	}
+	return nil, fmt.Errorf("unexpected %v", delim)
+}
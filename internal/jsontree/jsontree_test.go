@@ -0,0 +1,30 @@
+package jsontree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode_KeepsOrderAndNumbers(t *testing.T) {
+	raw := `{"z":1,"a":[true,null,"x",{"k":12345678901234567890}],"m":{}}`
+
+	v, err := Decode([]byte(raw))
+	require.NoError(t, err)
+	assert.Equal(t, Object{
+		{Key: "z", Value: json.Number("1")},
+		{Key: "a", Value: []any{true, nil, "x", Object{{Key: "k", Value: json.Number("12345678901234567890")}}}},
+		{Key: "m", Value: Object{}},
+	}, v)
+
+	out, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, raw, string(out))
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	_, err := Decode([]byte(`{"a":`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,168 @@
+// Package match scores how well a search query matches a name.
+package match
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type Kind string
+
+const (
+	KindExact  Kind = "exact"
+	KindPrefix Kind = "prefix"
+	KindToken  Kind = "token"
+	KindFuzzy  Kind = "fuzzy"
+	KindNone   Kind = ""
+)
+
+// Scores are in [0, 1]. Each kind has its own band so an exact match always
+// beats a prefix match, which always beats a token match, and so on.
+const (
+	exactScore = 1.0
+	prefixBase = 0.8
+	tokenBase  = 0.6
+	fuzzyBase  = 0.0
+	bandWidth  = 0.15
+
+	// minSimilarity is the lowest edit-distance similarity counted as a match.
+	minSimilarity = 0.6
+)
+
+// Normalize lowercases s and reduces punctuation and runs of spaces to a
+// single space, so "Guinea-Bissau" and "guinea bissau" compare equal.
+func Normalize(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+			continue
+		}
+		if r != '\'' && r != '’' {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// Score rates candidate against query.
+func Score(query, candidate string) (float64, Kind) {
+	q, c := Normalize(query), Normalize(candidate)
+	if q == "" || c == "" {
+		return 0, KindNone
+	}
+
+	if q == c {
+		return exactScore, KindExact
+	}
+	// A longer candidate covers less of itself with the query, so it
+	// scores lower within its band.
+	coverage := float64(len(q)) / float64(len(c))
+	if strings.HasPrefix(c, q) {
+		return prefixBase + bandWidth*coverage, KindPrefix
+	}
+	if containsTokens(c, q) {
+		return tokenBase + bandWidth*coverage, KindToken
+	}
+
+	if sim := Similarity(q, c); sim >= minSimilarity {
+		return fuzzyBase + 0.5*sim, KindFuzzy
+	}
+	return 0, KindNone
+}
+
+// Best scores every name and keeps the highest.
+func Best(query string, names []string) (float64, Kind) {
+	best, kind := 0.0, KindNone
+	for _, name := range names {
+		if score, k := Score(query, name); score > best {
+			best, kind = score, k
+		}
+	}
+	return best, kind
+}
+
+// Ranked is one item with its score against the query.
+type Ranked[T any] struct {
+	Item  T
+	Score float64
+	Kind  Kind
+}
+
+// Rank scores items by their names and returns the matches, best first.
+// Ties keep their input order.
+func Rank[T any](query string, items []T, names func(T) []string) []Ranked[T] {
+	var ranked []Ranked[T]
+	for _, item := range items {
+		score, kind := Best(query, names(item))
+		if kind == KindNone {
+			continue
+		}
+		ranked = append(ranked, Ranked[T]{Item: item, Score: score, Kind: kind})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked
+}
+
+// Similarity is 1 minus the edit distance scaled by the longer string.
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// Levenshtein returns the edit distance between a and b.
+func Levenshtein(a, b string) int {
+	return levenshtein([]rune(a), []rune(b))
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// containsTokens reports whether every word of q is a word of c.
+func containsTokens(c, q string) bool {
+	words := strings.Fields(c)
+	for _, token := range strings.Fields(q) {
+		found := false
+		for _, w := range words {
+			if w == token {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package match
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "guinea bissau", Normalize("Guinea-Bissau"))
+	assert.Equal(t, "korea republic of", Normalize("  Korea (Republic of) "))
+	assert.Equal(t, "cote divoire", Normalize("Cote d'Ivoire"))
+	assert.Equal(t, "", Normalize("--"))
+}
+
+func TestScore_Kinds(t *testing.T) {
+	tests := []struct {
+		query, candidate string
+		kind             Kind
+	}{
+		{"guinea", "Guinea", KindExact},
+		{"guinea bissau", "Guinea-Bissau", KindExact},
+		{"guinea", "Guinea-Bissau", KindPrefix},
+		{"guinea", "Equatorial Guinea", KindToken},
+		{"germny", "Germany", KindFuzzy},
+		{"brazil", "Japan", KindNone},
+		{"", "Japan", KindNone},
+	}
+
+	for _, tt := range tests {
+		_, kind := Score(tt.query, tt.candidate)
+		assert.Equal(t, tt.kind, kind, "%q vs %q", tt.query, tt.candidate)
+	}
+}
+
+func TestScore_BandsAreOrdered(t *testing.T) {
+	exact, _ := Score("niger", "Niger")
+	prefix, _ := Score("niger", "Nigeria")
+	token, _ := Score("guinea", "Papua New Guinea")
+	fuzzy, _ := Score("nigr", "Niger")
+
+	assert.Greater(t, exact, prefix)
+	assert.Greater(t, prefix, token)
+	assert.Greater(t, token, fuzzy)
+}
+
+func TestScore_ShorterCandidateWinsWithinBand(t *testing.T) {
+	short, _ := Score("guinea", "Guinea-Bissau")
+	long, _ := Score("guinea", "Guinea Equatorial Republic")
+
+	assert.Greater(t, short, long)
+}
+
+func TestBest(t *testing.T) {
+	score, kind := Best("UK", []string{"United Kingdom of Great Britain", "GB", "UK"})
+
+	assert.Equal(t, 1.0, score)
+	assert.Equal(t, KindExact, kind)
+}
+
+func TestRank(t *testing.T) {
+	names := []string{"Papua New Guinea", "Guinea-Bissau", "Guinea", "Equatorial Guinea", "Ghana"}
+
+	ranked := Rank("guinea", names, func(s string) []string { return []string{s} })
+
+	require.Len(t, ranked, 4)
+	assert.Equal(t, "Guinea", ranked[0].Item)
+	assert.Equal(t, KindExact, ranked[0].Kind)
+	assert.Equal(t, "Guinea-Bissau", ranked[1].Item)
+	// Both token matches; the shorter name covers more of itself.
+	assert.Equal(t, "Papua New Guinea", ranked[2].Item)
+	assert.Equal(t, "Equatorial Guinea", ranked[3].Item)
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, Levenshtein("france", "france"))
+	assert.Equal(t, 1, Levenshtein("germny", "germany"))
+	assert.Equal(t, 3, Levenshtein("kitten", "sitting"))
+	assert.Equal(t, 5, Levenshtein("", "spain"))
+	assert.Equal(t, 1, Levenshtein("türkiye", "turkiye"))
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, Similarity("", ""))
+	assert.InDelta(t, 6.0/7.0, Similarity("germny", "germany"), 1e-9)
+}
+
+func TestSoundex(t *testing.T) {
+	assert.Equal(t, "G655", Soundex("Germany"))
+	assert.Equal(t, "G650", Soundex("Germny"))
+	assert.Equal(t, "R163", Soundex("Robert"))
+	assert.Equal(t, "R163", Soundex("Rupert"))
+	assert.Equal(t, "A261", Soundex("Ashcraft"), "h does not separate s and c")
+	assert.Equal(t, "T522", Soundex("Tymczak"))
+	assert.Equal(t, "P236", Soundex("Pfister"))
+	assert.Equal(t, "C300", Soundex("Chad"))
+	assert.Equal(t, "", Soundex("日本"))
+}
+
+func TestConfidence(t *testing.T) {
+	assert.Equal(t, 1.0, Confidence("germany", "Germany"))
+	assert.Equal(t, 0.0, Confidence("", "Germany"))
+
+	spelled := Similarity("filipines", "philippines")
+	assert.InDelta(t, spelled+(1-spelled)/2, Confidence("Filipines", "Philippines"), 1e-9, "sounds alike")
+	assert.InDelta(t, Similarity("germny", "germany"), Confidence("Germny", "Germany"), 1e-9, "sounds different")
+	assert.Greater(t, Confidence("Kolombia", "Colombia"), Similarity("kolombia", "colombia"))
+	assert.Greater(t, Confidence("Oganda", "Uganda"), Similarity("oganda", "uganda"))
+	assert.Greater(t, Confidence("Swizerland", "Switzerland"), Confidence("Swizerland", "Swaziland"))
+}
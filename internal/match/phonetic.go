@@ -0,0 +1,86 @@
+package match
+
+import "strings"
+
+// phoneticWeight is how much of the gap to a perfect score sounding alike
+// closes, so "Germny" rates closer to "Germany" than its spelling alone
+// would put it.
+const phoneticWeight = 0.5
+
+// soundexCodes groups the consonants that sound alike. Vowels, h, w and y
+// have no code.
+var soundexCodes = map[rune]byte{
+	'b': '1', 'f': '1', 'p': '1', 'v': '1',
+	'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+	'd': '3', 't': '3',
+	'l': '4',
+	'm': '5', 'n': '5',
+	'r': '6',
+}
+
+// Soundex returns the American Soundex code of s, such as "G655" for
+// "Germany", or "" when s has no ASCII letters. Words are run together, so
+// a multi-word name has one code.
+func Soundex(s string) string {
+	var code []byte
+	var last byte
+	for _, r := range strings.ToLower(s) {
+		if r < 'a' || r > 'z' {
+			continue
+		}
+		digit := soundexCodes[r]
+		if code == nil {
+			code = append(code, byte(r-'a'+'A'))
+			last = digit
+			continue
+		}
+		// h and w don't separate consonants with the same code; vowels do.
+		if r == 'h' || r == 'w' {
+			continue
+		}
+		if digit != 0 && digit != last {
+			code = append(code, digit)
+			if len(code) == 4 {
+				break
+			}
+		}
+		last = digit
+	}
+	if code == nil {
+		return ""
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+// Confidence rates candidate as a correction of query, from 0 to 1. It is
+// their edit-distance similarity, raised when they sound alike.
+func Confidence(query, candidate string) float64 {
+	q, c := Normalize(query), Normalize(candidate)
+	if q == "" || c == "" {
+		return 0
+	}
+
+	sim := Similarity(q, c)
+	if soundAlike(Soundex(q), Soundex(c)) {
+		sim += (1 - sim) * phoneticWeight
+	}
+	return sim
+}
+
+// soundAlike compares Soundex codes, taking first letters that sound alike
+// as equal, so "Filipines" sounds like "Philippines" and "Kolombia" like
+// "Colombia".
+func soundAlike(a, b string) bool {
+	if a == "" || b == "" || a[1:] != b[1:] {
+		return false
+	}
+	x, y := rune(a[0]-'A'+'a'), rune(b[0]-'A'+'a')
+	return x == y || soundexCodes[x] != 0 && soundexCodes[x] == soundexCodes[y] || isVowel(x) && isVowel(y)
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiou", r)
+}
@@ -0,0 +1,221 @@
+// Package mockupstream imitates the country upstreams — apicountries.com
+// and REST Countries — from a local dataset or recorded fixtures, with
+// injectable faults so retries, breakers and limiters can be exercised
+// without a network.
+package mockupstream
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/httpfixture"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Faults configures the misbehaviour injected into responses. Rates are
+// probabilities between 0 and 1, rolled independently per request in the
+// order throttle, error, malformed.
+type Faults struct {
+	Latency time.Duration
+	// Jitter adds up to this much random latency on top.
+	Jitter        time.Duration
+	ThrottleRate  float64
+	ErrorRate     float64
+	MalformedRate float64
+	// Seed makes the fault sequence reproducible. Zero picks a random one.
+	Seed uint64
+}
+
+type Server struct {
+	countries []externalapi.Country
+	faults    Faults
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// New serves countries in both upstream formats.
+func New(countries []externalapi.Country, faults Faults) *Server {
+	seed := faults.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+
+	return &Server{
+		countries: countries,
+		faults:    faults,
+		rng:       rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// Handler serves the apicountries.com and REST Countries v3.1 routes.
+func (s *Server) Handler() http.Handler {
+	r := httprouter.New()
+
+	r.HandlerFunc(http.MethodGet, "/countries", s.listAPICountries)
+	r.GET("/name/:name", s.searchAPICountries)
+
+	r.HandlerFunc(http.MethodGet, "/v3.1/all", s.listRESTCountries)
+	r.GET("/v3.1/name/:name", s.searchRESTCountries)
+	r.GET("/v3.1/alpha/:code", s.restCountryByCode)
+
+	return s.withFaults(r)
+}
+
+// FixtureHandler serves recorded fixtures instead of a dataset.
+func (s *Server) FixtureHandler(rep *httpfixture.Replayer) http.Handler {
+	return s.withFaults(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := rep.RoundTrip(r.Clone(r.Context()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		defer resp.Body.Close()
+
+		for name, values := range resp.Header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+}
+
+func (s *Server) listAPICountries(w http.ResponseWriter, r *http.Request) {
+	out := make([]apiCountry, len(s.countries))
+	for i, c := range s.countries {
+		out[i] = toAPICountry(c)
+	}
+	writeCacheable(w, r, out)
+}
+
+func (s *Server) searchAPICountries(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var out []apiCountry
+	for _, c := range s.countries {
+		if matchesName(c, ps.ByName("name"), false) {
+			out = append(out, toAPICountry(c))
+		}
+	}
+	if len(out) == 0 {
+		writeNotFound(w)
+		return
+	}
+	writeCacheable(w, r, out)
+}
+
+func (s *Server) listRESTCountries(w http.ResponseWriter, r *http.Request) {
+	out := make([]restCountry, len(s.countries))
+	for i, c := range s.countries {
+		out[i] = toRESTCountry(c)
+	}
+	writeCacheable(w, r, out)
+}
+
+func (s *Server) searchRESTCountries(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	fullText := r.URL.Query().Get("fullText") == "true"
+	var out []restCountry
+	for _, c := range s.countries {
+		if matchesName(c, ps.ByName("name"), fullText) {
+			out = append(out, toRESTCountry(c))
+		}
+	}
+	if len(out) == 0 {
+		writeNotFound(w)
+		return
+	}
+	writeCacheable(w, r, out)
+}
+
+func (s *Server) restCountryByCode(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	code := strings.ToUpper(ps.ByName("code"))
+	for _, c := range s.countries {
+		if code == c.Alpha2Code || code == c.Alpha3Code || code == c.NumericCode {
+			writeCacheable(w, r, []restCountry{toRESTCountry(c)})
+			return
+		}
+	}
+	writeNotFound(w)
+}
+
+// withFaults delays and breaks responses as configured before handing
+// the request to next.
+func (s *Server) withFaults(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay, throttle, fail, malformed := s.roll()
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		switch {
+		case throttle:
+			w.Header().Set("Retry-After", "1")
+			writeJSON(w, http.StatusTooManyRequests, map[string]any{"status": 429, "message": "Too Many Requests"})
+		case fail:
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"status": 500, "message": "Internal Server Error"})
+		case malformed:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write([]byte(`[{"name": "Fra`))
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+func (s *Server) roll() (delay time.Duration, throttle, fail, malformed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delay = s.faults.Latency
+	if s.faults.Jitter > 0 {
+		delay += time.Duration(s.rng.Int64N(int64(s.faults.Jitter)))
+	}
+	throttle = s.rng.Float64() < s.faults.ThrottleRate
+	fail = s.rng.Float64() < s.faults.ErrorRate
+	malformed = s.rng.Float64() < s.faults.MalformedRate
+	return delay, throttle, fail, malformed
+}
+
+// writeCacheable writes v with an ETag, answering 304 when the client
+// already has it, as the real upstreams do.
+func writeCacheable(w http.ResponseWriter, r *http.Request, v any) {
+	jsonResp, err := json.Marshal(v)
+	if err != nil {
+		log.Fatalf("error handling JSON marshal. Err: %v", err)
+	}
+	sum := sha256.Sum256(jsonResp)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(jsonResp)
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]any{"status": 404, "message": "Not Found"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	jsonResp, err := json.Marshal(v)
+	if err != nil {
+		log.Fatalf("error handling JSON marshal. Err: %v", err)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(jsonResp)
+}
@@ -0,0 +1,153 @@
+package mockupstream
+
+import (
+	"CountrySearch/internal/dataset"
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/httpfixture"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, h http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHandler_ServesAPICountriesShape(t *testing.T) {
+	srv := serve(t, New(dataset.Countries(), Faults{}).Handler())
+	p := externalapi.NewAPICountriesProvider(srv.URL, srv.Client())
+
+	france, err := externalapi.FetchCountry(context.Background(), p, "France")
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", france.Capital)
+	assert.Equal(t, "FRA", france.Alpha3Code)
+
+	all, err := p.ListCountries(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all, len(dataset.Countries()))
+
+	_, err = p.SearchCountries(context.Background(), "Atlantis")
+	assert.ErrorIs(t, err, externalapi.ErrCountryNotFound)
+}
+
+func TestHandler_ServesRESTCountriesShape(t *testing.T) {
+	srv := serve(t, New(dataset.Countries(), Faults{}).Handler())
+
+	resp, err := srv.Client().Get(srv.URL + "/v3.1/alpha/pa")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var countries []restCountry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&countries))
+	require.Len(t, countries, 1)
+	panama := countries[0]
+	assert.Equal(t, "Panama", panama.Name.Common)
+	assert.Equal(t, []string{"Panama City"}, panama.Capital)
+	assert.Contains(t, panama.Currencies, "PAB")
+	assert.Contains(t, panama.Currencies, "USD")
+}
+
+func TestHandler_RESTFullTextSearch(t *testing.T) {
+	srv := serve(t, New(dataset.Countries(), Faults{}).Handler())
+
+	for path, want := range map[string]int{
+		"/v3.1/name/guinea":               4,
+		"/v3.1/name/guinea?fullText=true": 1,
+		"/v3.1/name/atlantis":             0,
+	} {
+		resp, err := srv.Client().Get(srv.URL + path)
+		require.NoError(t, err)
+		var countries []restCountry
+		_ = json.NewDecoder(resp.Body).Decode(&countries)
+		resp.Body.Close()
+		assert.Len(t, countries, want, path)
+	}
+}
+
+func TestFaults_Throttle(t *testing.T) {
+	srv := serve(t, New(dataset.Countries(), Faults{ThrottleRate: 1}).Handler())
+
+	resp, err := srv.Client().Get(srv.URL + "/countries")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+}
+
+func TestFaults_ErrorAndMalformed(t *testing.T) {
+	srv := serve(t, New(dataset.Countries(), Faults{ErrorRate: 1}).Handler())
+	p := externalapi.NewAPICountriesProvider(srv.URL, srv.Client())
+	_, err := p.SearchCountries(context.Background(), "France")
+	assert.ErrorContains(t, err, "status 500")
+
+	srv = serve(t, New(dataset.Countries(), Faults{MalformedRate: 1}).Handler())
+	p = externalapi.NewAPICountriesProvider(srv.URL, srv.Client())
+	_, err = p.SearchCountries(context.Background(), "France")
+	assert.Error(t, err)
+}
+
+func TestFaults_Latency(t *testing.T) {
+	srv := serve(t, New(dataset.Countries(), Faults{Latency: 50 * time.Millisecond}).Handler())
+
+	start := time.Now()
+	resp, err := srv.Client().Get(srv.URL + "/name/France")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestFaults_SeedIsReproducible(t *testing.T) {
+	a := New(nil, Faults{ErrorRate: 0.5, Seed: 42})
+	b := New(nil, Faults{ErrorRate: 0.5, Seed: 42})
+
+	for i := 0; i < 20; i++ {
+		_, _, failA, _ := a.roll()
+		_, _, failB, _ := b.roll()
+		assert.Equal(t, failA, failB)
+	}
+}
+
+func TestFixtureHandler(t *testing.T) {
+	rep, err := httpfixture.NewReplayer("../externalapi/testdata/fixtures")
+	require.NoError(t, err)
+	srv := serve(t, New(nil, Faults{}).FixtureHandler(rep))
+	p := externalapi.NewAPICountriesProvider(srv.URL, srv.Client())
+
+	countries, err := p.SearchCountries(context.Background(), "Korea")
+	require.NoError(t, err)
+	assert.Len(t, countries, 2)
+
+	_, err = p.SearchCountries(context.Background(), "Chile")
+	assert.ErrorIs(t, err, externalapi.ErrCountryNotFound, "unrecorded requests are 404s")
+}
+
+func TestHandler_AnswersConditionalRequests(t *testing.T) {
+	srv := serve(t, New(dataset.Countries(), Faults{}).Handler())
+
+	resp, err := srv.Client().Get(srv.URL + "/name/France")
+	require.NoError(t, err)
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/name/France", nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = srv.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+}
@@ -0,0 +1,200 @@
+package mockupstream
+
+import (
+	"CountrySearch/internal/externalapi"
+	"strings"
+)
+
+// apiCountry is a country in apicountries.com's wire format.
+type apiCountry struct {
+	Name           string        `json:"name"`
+	NativeName     string        `json:"nativeName,omitempty"`
+	AltSpellings   []string      `json:"altSpellings"`
+	Alpha2Code     string        `json:"alpha2Code"`
+	Alpha3Code     string        `json:"alpha3Code"`
+	NumericCode    string        `json:"numericCode,omitempty"`
+	Capital        string        `json:"capital,omitempty"`
+	Region         string        `json:"region"`
+	Subregion      string        `json:"subregion,omitempty"`
+	Population     int           `json:"population"`
+	Area           float64       `json:"area,omitempty"`
+	LatLng         []float64     `json:"latlng,omitempty"`
+	Timezones      []string      `json:"timezones"`
+	CallingCodes   []string      `json:"callingCodes"`
+	TopLevelDomain []string      `json:"topLevelDomain,omitempty"`
+	Demonym        string        `json:"demonym,omitempty"`
+	Borders        []string      `json:"borders,omitempty"`
+	Flag           string        `json:"flag"`
+	Flags          apiFlags      `json:"flags"`
+	Languages      []apiLanguage `json:"languages"`
+	Currencies     []apiCurrency `json:"currencies,omitempty"`
+}
+
+type apiFlags struct {
+	SVG string `json:"svg"`
+	PNG string `json:"png"`
+}
+
+type apiLanguage struct {
+	ISO639_1   string `json:"iso639_1,omitempty"`
+	ISO639_2   string `json:"iso639_2"`
+	Name       string `json:"name"`
+	NativeName string `json:"nativeName,omitempty"`
+}
+
+type apiCurrency struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+}
+
+func toAPICountry(c externalapi.Country) apiCountry {
+	out := apiCountry{
+		Name:           c.Name,
+		NativeName:     c.NativeName,
+		AltSpellings:   c.AltSpellings,
+		Alpha2Code:     c.Alpha2Code,
+		Alpha3Code:     c.Alpha3Code,
+		NumericCode:    c.NumericCode,
+		Capital:        c.Capital,
+		Region:         c.Region,
+		Subregion:      c.Subregion,
+		Population:     c.Population,
+		Area:           c.Area,
+		LatLng:         c.LatLng,
+		Timezones:      c.Timezones,
+		CallingCodes:   c.CallingCodes,
+		TopLevelDomain: c.TopLevelDomains,
+		Demonym:        c.Demonym,
+		Borders:        c.Borders,
+		Flag:           c.Flags.SVG,
+		Flags:          apiFlags{SVG: c.Flags.SVG, PNG: c.Flags.PNG},
+		Languages:      []apiLanguage{},
+	}
+	for _, l := range c.Languages {
+		out.Languages = append(out.Languages, apiLanguage{
+			ISO639_1:   l.ISO639_1,
+			ISO639_2:   l.ISO639_2,
+			Name:       l.Name,
+			NativeName: l.NativeName,
+		})
+	}
+	for _, cur := range c.Currencies {
+		out.Currencies = append(out.Currencies, apiCurrency{Code: cur.Code, Name: cur.Name, Symbol: cur.Symbol})
+	}
+	return out
+}
+
+// restCountry is a country in REST Countries' v3.1 wire format.
+type restCountry struct {
+	Name         restName                `json:"name"`
+	TLD          []string                `json:"tld,omitempty"`
+	CCA2         string                  `json:"cca2"`
+	CCN3         string                  `json:"ccn3,omitempty"`
+	CCA3         string                  `json:"cca3"`
+	Currencies   map[string]restCurrency `json:"currencies,omitempty"`
+	IDD          restIDD                 `json:"idd"`
+	Capital      []string                `json:"capital,omitempty"`
+	AltSpellings []string                `json:"altSpellings"`
+	Region       string                  `json:"region"`
+	Subregion    string                  `json:"subregion,omitempty"`
+	Languages    map[string]string       `json:"languages,omitempty"`
+	LatLng       []float64               `json:"latlng"`
+	Borders      []string                `json:"borders,omitempty"`
+	Area         float64                 `json:"area"`
+	Demonyms     map[string]restDemonym  `json:"demonyms,omitempty"`
+	Population   int                     `json:"population"`
+	Timezones    []string                `json:"timezones"`
+	Flags        restFlags               `json:"flags"`
+}
+
+type restName struct {
+	Common     string                  `json:"common"`
+	Official   string                  `json:"official"`
+	NativeName map[string]restNameForm `json:"nativeName,omitempty"`
+}
+
+type restNameForm struct {
+	Official string `json:"official"`
+	Common   string `json:"common"`
+}
+
+type restCurrency struct {
+	Name   string `json:"name"`
+	Symbol string `json:"symbol,omitempty"`
+}
+
+type restIDD struct {
+	Root     string   `json:"root,omitempty"`
+	Suffixes []string `json:"suffixes,omitempty"`
+}
+
+type restDemonym struct {
+	F string `json:"f"`
+	M string `json:"m"`
+}
+
+type restFlags struct {
+	PNG string `json:"png"`
+	SVG string `json:"svg"`
+}
+
+func toRESTCountry(c externalapi.Country) restCountry {
+	out := restCountry{
+		Name:         restName{Common: c.Name, Official: c.Name},
+		TLD:          c.TopLevelDomains,
+		CCA2:         c.Alpha2Code,
+		CCN3:         c.NumericCode,
+		CCA3:         c.Alpha3Code,
+		AltSpellings: c.AltSpellings,
+		Region:       c.Region,
+		Subregion:    c.Subregion,
+		LatLng:       c.LatLng,
+		Borders:      c.Borders,
+		Area:         c.Area,
+		Population:   c.Population,
+		Timezones:    c.Timezones,
+		Flags:        restFlags{PNG: c.Flags.PNG, SVG: c.Flags.SVG},
+	}
+	if c.Capital != "" {
+		out.Capital = []string{c.Capital}
+	}
+	if len(c.CallingCodes) > 0 {
+		// REST Countries splits "+1" "684" style codes; a single root
+		// with no suffix is close enough for a mock.
+		out.IDD.Root = "+" + c.CallingCodes[0]
+	}
+	if c.Demonym != "" {
+		out.Demonyms = map[string]restDemonym{"eng": {F: c.Demonym, M: c.Demonym}}
+	}
+	if len(c.Languages) > 0 {
+		out.Languages = make(map[string]string, len(c.Languages))
+		for _, l := range c.Languages {
+			out.Languages[l.ISO639_2] = l.Name
+		}
+		if c.NativeName != "" {
+			code := c.Languages[0].ISO639_2
+			out.Name.NativeName = map[string]restNameForm{code: {Official: c.NativeName, Common: c.NativeName}}
+		}
+	}
+	if len(c.Currencies) > 0 {
+		out.Currencies = make(map[string]restCurrency, len(c.Currencies))
+		for _, cur := range c.Currencies {
+			out.Currencies[cur.Code] = restCurrency{Name: cur.Name, Symbol: cur.Symbol}
+		}
+	}
+	return out
+}
+
+// matchesName reports whether name is a substring of any of c's names,
+// or equal to one of them when fullText is set.
+func matchesName(c externalapi.Country, name string, fullText bool) bool {
+	name = strings.ToLower(name)
+	for _, n := range c.Names() {
+		n = strings.ToLower(n)
+		if n == name || (!fullText && strings.Contains(n, name)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+// Package probe checks each upstream provider in the background with a
+// lookup that should always succeed, and keeps the recent results so
+// callers can route around providers that are down.
+package probe
+
+import (
+	"CountrySearch/internal/externalapi"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	Interval         time.Duration // time between probes, 0 disables probing
+	Timeout          time.Duration // limit on a single probe
+	Query            string        // country every provider must find
+	History          int           // results kept per provider
+	FailureThreshold int           // consecutive failures before a provider is unhealthy
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Interval:         30 * time.Second,
+		Timeout:          5 * time.Second,
+		Query:            "France",
+		History:          20,
+		FailureThreshold: 3,
+	}
+}
+
+// Result is the outcome of one probe.
+type Result struct {
+	At        time.Time `json:"at"`
+	LatencyMS float64   `json:"latency_ms"`
+	OK        bool      `json:"ok"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Status is one provider's probe history and the health verdict drawn
+// from it.
+type Status struct {
+	Name                string     `json:"name"`
+	Healthy             bool       `json:"healthy"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	SuccessRate         float64    `json:"success_rate"`
+	AvgLatencyMS        float64    `json:"avg_latency_ms"`
+	LastCheck           *time.Time `json:"last_check,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	History             []Result   `json:"history"`
+}
+
+type target struct {
+	provider externalapi.CountryProvider
+
+	history     []Result // oldest first
+	failures    int
+	lastSuccess time.Time
+}
+
+// Prober probes its providers as they are given. Outside any breaker, it
+// measures the upstream itself; behind a rate limiter, probes spend from
+// its budget, and a probe it turns away is skipped rather than counted as a
+// failure.
+type Prober struct {
+	cfg Config
+
+	mu      sync.Mutex
+	targets []*target
+}
+
+func New(cfg Config, providers ...externalapi.CountryProvider) *Prober {
+	def := DefaultConfig()
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = def.Timeout
+	}
+	if cfg.Query == "" {
+		cfg.Query = def.Query
+	}
+	if cfg.History <= 0 {
+		cfg.History = def.History
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = def.FailureThreshold
+	}
+
+	p := &Prober{cfg: cfg}
+	for _, provider := range providers {
+		p.targets = append(p.targets, &target{provider: provider})
+	}
+	return p
+}
+
+// Run probes straight away and then every interval until ctx is done.
+func (p *Prober) Run(ctx context.Context) {
+	if p.cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		p.ProbeAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProbeAll probes every provider concurrently and waits for the results.
+func (p *Prober) ProbeAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, t := range p.targets {
+		wg.Go(func() {
+			p.probe(ctx, t)
+		})
+	}
+	wg.Wait()
+}
+
+func (p *Prober) probe(ctx context.Context, t *target) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	countries, err := t.provider.SearchCountries(ctx, p.cfg.Query)
+	if err == nil && !found(countries, p.cfg.Query) {
+		err = fmt.Errorf("%q missing from the response", p.cfg.Query)
+	}
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// Shutting down; the result says nothing about the provider.
+		return
+	}
+	if errors.Is(err, externalapi.ErrUnavailable) {
+		// The provider wasn't asked, e.g. the rate limit is spent.
+		return
+	}
+	result := Result{At: start, LatencyMS: float64(time.Since(start)) / float64(time.Millisecond), OK: err == nil}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		result.Error = err.Error()
+		t.failures++
+		if t.failures == p.cfg.FailureThreshold {
+			log.Printf("upstream %s failed %d probes in a row: %v", t.provider.Name(), t.failures, err)
+		}
+	} else {
+		if t.failures >= p.cfg.FailureThreshold {
+			log.Printf("upstream %s is answering probes again", t.provider.Name())
+		}
+		t.failures = 0
+		t.lastSuccess = start
+	}
+	if len(t.history) == p.cfg.History {
+		t.history = append(t.history[:0], t.history[1:]...)
+	}
+	t.history = append(t.history, result)
+}
+
+func found(countries []externalapi.Country, name string) bool {
+	for _, c := range countries {
+		if strings.EqualFold(c.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Healthy reports whether the named provider is passing its probes.
+// Providers that are not probed, or not probed yet, count as healthy.
+func (p *Prober) Healthy(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, t := range p.targets {
+		if t.provider.Name() == name {
+			return t.failures < p.cfg.FailureThreshold
+		}
+	}
+	return true
+}
+
+// AnyHealthy reports whether at least one provider is passing its probes.
+func (p *Prober) AnyHealthy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, t := range p.targets {
+		if t.failures < p.cfg.FailureThreshold {
+			return true
+		}
+	}
+	return len(p.targets) == 0
+}
+
+func (p *Prober) Status() []Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	statuses := make([]Status, 0, len(p.targets))
+	for _, t := range p.targets {
+		status := Status{
+			Name:                t.provider.Name(),
+			Healthy:             t.failures < p.cfg.FailureThreshold,
+			ConsecutiveFailures: t.failures,
+			History:             append([]Result(nil), t.history...),
+		}
+		if n := len(t.history); n > 0 {
+			last := t.history[n-1]
+			status.LastCheck = &last.At
+			status.LastError = last.Error
+
+			ok, latency := 0, 0.0
+			for _, r := range t.history {
+				if r.OK {
+					ok++
+				}
+				latency += r.LatencyMS
+			}
+			status.SuccessRate = float64(ok) / float64(n)
+			status.AvgLatencyMS = latency / float64(n)
+		}
+		if !t.lastSuccess.IsZero() {
+			lastSuccess := t.lastSuccess
+			status.LastSuccess = &lastSuccess
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
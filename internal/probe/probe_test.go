@@ -0,0 +1,184 @@
+package probe
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/ratelimit"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type probeProvider struct {
+	name string
+
+	mu        sync.Mutex
+	err       error
+	countries []externalapi.Country
+	queries   []string
+}
+
+func (p *probeProvider) Name() string { return p.name }
+
+func (p *probeProvider) SearchCountries(ctx context.Context, name string) ([]externalapi.Country, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queries = append(p.queries, name)
+	return p.countries, p.err
+}
+
+func (p *probeProvider) ListCountries(ctx context.Context) ([]externalapi.Country, error) {
+	return nil, errors.New("not used")
+}
+
+func (p *probeProvider) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func healthyProvider(name string) *probeProvider {
+	return &probeProvider{name: name, countries: []externalapi.Country{{Name: "France"}}}
+}
+
+func TestProber_UnprobedProvidersAreHealthy(t *testing.T) {
+	p := New(DefaultConfig(), healthyProvider("a"))
+
+	assert.True(t, p.Healthy("a"))
+	assert.True(t, p.Healthy("unknown"))
+	assert.True(t, p.AnyHealthy())
+
+	status := p.Status()
+	require.Len(t, status, 1)
+	assert.True(t, status[0].Healthy)
+	assert.Nil(t, status[0].LastCheck)
+	assert.Empty(t, status[0].History)
+}
+
+func TestProber_RecordsSuccess(t *testing.T) {
+	provider := healthyProvider("a")
+	p := New(Config{Query: "france"}, provider)
+
+	p.ProbeAll(context.Background())
+
+	assert.Equal(t, []string{"france"}, provider.queries)
+	status := p.Status()[0]
+	assert.True(t, status.Healthy)
+	assert.Equal(t, 1.0, status.SuccessRate)
+	require.NotNil(t, status.LastSuccess)
+	assert.Equal(t, status.LastCheck, status.LastSuccess)
+	require.Len(t, status.History, 1)
+	assert.True(t, status.History[0].OK)
+}
+
+func TestProber_UnhealthyAfterThreshold(t *testing.T) {
+	provider := healthyProvider("a")
+	provider.fail(errors.New("api returned status 500"))
+	p := New(Config{FailureThreshold: 2}, provider)
+
+	p.ProbeAll(context.Background())
+	assert.True(t, p.Healthy("a"), "one failure is not enough")
+
+	p.ProbeAll(context.Background())
+	assert.False(t, p.Healthy("a"))
+	assert.False(t, p.AnyHealthy())
+	status := p.Status()[0]
+	assert.Equal(t, 2, status.ConsecutiveFailures)
+	assert.Equal(t, 0.0, status.SuccessRate)
+	assert.Equal(t, "api returned status 500", status.LastError)
+
+	provider.fail(nil)
+	p.ProbeAll(context.Background())
+	assert.True(t, p.Healthy("a"), "one success restores the provider")
+	assert.InDelta(t, 1.0/3, p.Status()[0].SuccessRate, 1e-9)
+}
+
+func TestProber_WrongAnswerIsAFailure(t *testing.T) {
+	provider := &probeProvider{name: "a", countries: []externalapi.Country{{Name: "Germany"}}}
+	p := New(Config{FailureThreshold: 1}, provider)
+
+	p.ProbeAll(context.Background())
+
+	assert.False(t, p.Healthy("a"))
+	assert.Contains(t, p.Status()[0].LastError, `"France" missing`)
+}
+
+func TestProber_AnyHealthy(t *testing.T) {
+	down := healthyProvider("down")
+	down.fail(errors.New("boom"))
+	p := New(Config{FailureThreshold: 1}, down, healthyProvider("up"))
+
+	p.ProbeAll(context.Background())
+
+	assert.False(t, p.Healthy("down"))
+	assert.True(t, p.Healthy("up"))
+	assert.True(t, p.AnyHealthy())
+}
+
+func TestProber_HistoryIsBounded(t *testing.T) {
+	p := New(Config{History: 3}, healthyProvider("a"))
+
+	for range 5 {
+		p.ProbeAll(context.Background())
+	}
+
+	history := p.Status()[0].History
+	require.Len(t, history, 3)
+	assert.False(t, history[2].At.Before(history[0].At), "oldest first")
+}
+
+func TestProber_IgnoresShutdown(t *testing.T) {
+	provider := healthyProvider("a")
+	provider.fail(context.Canceled)
+	p := New(Config{FailureThreshold: 1}, provider)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p.ProbeAll(ctx)
+
+	assert.True(t, p.Healthy("a"))
+	assert.Empty(t, p.Status()[0].History)
+}
+
+func TestProber_ProbesSpendRateLimitBudget(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Config{DailyQuota: 1})
+	p := New(Config{FailureThreshold: 1, Timeout: time.Second, Query: "France", History: 10},
+		externalapi.WithRateLimit(healthyProvider("a"), limiter))
+
+	p.ProbeAll(context.Background())
+	p.ProbeAll(context.Background())
+
+	assert.Equal(t, 1, limiter.Snapshot().QuotaUsed)
+	assert.True(t, p.Healthy("a"), "a probe the limiter held back is not a failure")
+	assert.Len(t, p.Status()[0].History, 1)
+}
+
+func TestProber_RunProbesUntilCancelled(t *testing.T) {
+	provider := healthyProvider("a")
+	p := New(Config{Interval: 10 * time.Millisecond}, provider)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return len(p.Status()[0].History) >= 2
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestProber_RunDisabled(t *testing.T) {
+	provider := healthyProvider("a")
+	p := New(Config{}, provider)
+
+	p.Run(context.Background())
+
+	assert.Empty(t, provider.queries)
+}
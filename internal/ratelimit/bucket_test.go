@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.t }
+
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func TestBucket_AllowsBurstThenLimits(t *testing.T) {
+	clock := newFakeClock()
+	b := NewBucket(1, 3)
+	b.now = clock.Now
+
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+}
+
+func TestBucket_Refills(t *testing.T) {
+	clock := newFakeClock()
+	b := NewBucket(2, 1)
+	b.now = clock.Now
+
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+
+	clock.Advance(500 * time.Millisecond)
+	assert.True(t, b.Allow())
+}
+
+func TestBucket_RefillCappedAtBurst(t *testing.T) {
+	clock := newFakeClock()
+	b := NewBucket(10, 2)
+	b.now = clock.Now
+	b.Allow()
+
+	clock.Advance(time.Hour)
+
+	assert.Equal(t, 2.0, b.Tokens())
+}
+
+func TestBucket_WaitRejectsBeyondDeadline(t *testing.T) {
+	b := NewBucket(0.1, 1)
+	assert.True(t, b.Allow())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, b.Wait(ctx), ErrLimited)
+	assert.InDelta(t, 0, b.Tokens(), 0.01, "rejected waits must hand their token back")
+}
+
+func TestBucket_WaitQueuesWithinDeadline(t *testing.T) {
+	b := NewBucket(100, 1)
+	assert.True(t, b.Allow())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	assert.NoError(t, b.Wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond)
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Quota counts upstream calls per UTC day. When path is set the counter is
+// written to disk after every call so a restart does not reset the budget.
+type Quota struct {
+	limit int
+	path  string
+	now   func() time.Time
+
+	mu   sync.Mutex
+	day  string
+	used int
+}
+
+type quotaFile struct {
+	Day  string `json:"day"`
+	Used int    `json:"used"`
+}
+
+func NewQuota(limit int, path string) *Quota {
+	q := &Quota{
+		limit: limit,
+		path:  path,
+		now:   time.Now,
+	}
+	q.load()
+	return q
+}
+
+// Take counts one call against the quota, or returns ErrQuotaExhausted.
+func (q *Quota) Take() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.roll()
+	if q.limit > 0 && q.used >= q.limit {
+		return ErrQuotaExhausted
+	}
+	q.used++
+	q.save()
+	return nil
+}
+
+// Used returns the calls counted today and the daily limit (0 = unlimited).
+func (q *Quota) Used() (used, limit int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.roll()
+	return q.used, q.limit
+}
+
+func (q *Quota) today() string {
+	return q.now().UTC().Format(time.DateOnly)
+}
+
+func (q *Quota) roll() {
+	if day := q.today(); day != q.day {
+		q.day = day
+		q.used = 0
+	}
+}
+
+func (q *Quota) load() {
+	q.day = q.today()
+	if q.path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Printf("error reading quota file %s: %v", q.path, err)
+		return
+	}
+
+	var f quotaFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		log.Printf("INVALID: quota file %s: %v, starting from zero", q.path, err)
+		return
+	}
+	if f.Day == q.day {
+		q.used = f.Used
+	}
+}
+
+func (q *Quota) save() {
+	if q.path == "" {
+		return
+	}
+
+	data, err := json.Marshal(quotaFile{Day: q.day, Used: q.used})
+	if err != nil {
+		log.Printf("error encoding quota: %v", err)
+		return
+	}
+
+	// Write then rename so a crash never leaves a half-written file.
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), ".quota-*")
+	if err != nil {
+		log.Printf("error saving quota: %v", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		log.Printf("error saving quota: %v", err)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		log.Printf("error saving quota: %v", err)
+		return
+	}
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		log.Printf("error saving quota: %v", err)
+	}
+}
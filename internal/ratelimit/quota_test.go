@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuota_EnforcesLimit(t *testing.T) {
+	q := NewQuota(2, "")
+
+	assert.NoError(t, q.Take())
+	assert.NoError(t, q.Take())
+	assert.ErrorIs(t, q.Take(), ErrQuotaExhausted)
+
+	used, limit := q.Used()
+	assert.Equal(t, 2, used)
+	assert.Equal(t, 2, limit)
+}
+
+func TestQuota_ZeroLimitIsUnlimited(t *testing.T) {
+	q := NewQuota(0, "")
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, q.Take())
+	}
+}
+
+func TestQuota_ResetsAtMidnightUTC(t *testing.T) {
+	clock := newFakeClock()
+	q := NewQuota(1, "")
+	q.now = clock.Now
+	q.day = q.today()
+
+	require.NoError(t, q.Take())
+	require.ErrorIs(t, q.Take(), ErrQuotaExhausted)
+
+	clock.Advance(24 * time.Hour)
+	assert.NoError(t, q.Take())
+}
+
+func TestQuota_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+
+	q := NewQuota(3, path)
+	require.NoError(t, q.Take())
+	require.NoError(t, q.Take())
+
+	restarted := NewQuota(3, path)
+	used, _ := restarted.Used()
+	assert.Equal(t, 2, used)
+	assert.NoError(t, restarted.Take())
+	assert.ErrorIs(t, restarted.Take(), ErrQuotaExhausted)
+}
+
+func TestQuota_IgnoresPreviousDay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"day":"2000-01-01","used":99}`), 0o644))
+
+	q := NewQuota(5, path)
+
+	used, _ := q.Used()
+	assert.Equal(t, 0, used)
+}
+
+func TestQuota_CorruptFileStartsFromZero(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	require.NoError(t, os.WriteFile(path, []byte(`not json`), 0o644))
+
+	q := NewQuota(5, path)
+
+	used, _ := q.Used()
+	assert.Equal(t, 0, used)
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrLimited        = errors.New("upstream rate limit exceeded")
+	ErrQuotaExhausted = errors.New("upstream daily quota exhausted")
+)
+
+// Mode decides what happens to a call once the budget is spent.
+type Mode string
+
+const (
+	ModeQueue Mode = "queue" // wait for a token up to MaxWait
+	ModeStale Mode = "stale" // reject at once; callers may serve stale data
+	ModeFail  Mode = "fail"  // reject at once; callers should not serve stale data
+)
+
+type Config struct {
+	Rate       float64       // calls per second, 0 disables the token bucket
+	Burst      int           // calls allowed back to back
+	DailyQuota int           // calls per UTC day, 0 means unlimited
+	QuotaFile  string        // where the daily counter is persisted, empty keeps it in memory
+	Mode       Mode          // behaviour when the budget is exhausted
+	MaxWait    time.Duration // longest a queued call waits for a token
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Rate:    5,
+		Burst:   10,
+		Mode:    ModeQueue,
+		MaxWait: 2 * time.Second,
+	}
+}
+
+// Snapshot holds the tokens left in the bucket and the calls counted
+// against today's quota.
+type Snapshot struct {
+	Mode       Mode    `json:"mode"`
+	Tokens     float64 `json:"tokens"`
+	QuotaUsed  int     `json:"quota_used"`
+	QuotaLimit int     `json:"quota_limit,omitempty"`
+}
+
+// Limiter combines a token bucket with a daily quota.
+type Limiter struct {
+	bucket  *Bucket
+	quota   *Quota
+	mode    Mode
+	maxWait time.Duration
+}
+
+func New(cfg Config) *Limiter {
+	l := &Limiter{
+		quota:   NewQuota(cfg.DailyQuota, cfg.QuotaFile),
+		mode:    cfg.Mode,
+		maxWait: cfg.MaxWait,
+	}
+	if cfg.Rate > 0 {
+		l.bucket = NewBucket(cfg.Rate, cfg.Burst)
+	}
+	switch l.mode {
+	case ModeQueue, ModeStale, ModeFail:
+	default:
+		l.mode = ModeQueue
+	}
+	return l
+}
+
+// Acquire spends one unit of budget, waiting for it in queue mode.
+func (l *Limiter) Acquire(ctx context.Context) error {
+	if l.bucket != nil {
+		if l.mode == ModeQueue {
+			ctx, cancel := context.WithTimeout(ctx, l.maxWait)
+			defer cancel()
+			if err := l.bucket.Wait(ctx); err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					return ErrLimited
+				}
+				return err
+			}
+		} else if !l.bucket.Allow() {
+			return ErrLimited
+		}
+	}
+
+	return l.quota.Take()
+}
+
+func (l *Limiter) Mode() Mode {
+	return l.mode
+}
+
+func (l *Limiter) Snapshot() Snapshot {
+	used, limit := l.quota.Used()
+	snap := Snapshot{
+		Mode:       l.mode,
+		QuotaUsed:  used,
+		QuotaLimit: limit,
+	}
+	if l.bucket != nil {
+		snap.Tokens = l.bucket.Tokens()
+	}
+	return snap
+}
+
+// IsLimited reports whether err came from an exhausted budget.
+func IsLimited(err error) bool {
+	return errors.Is(err, ErrLimited) || errors.Is(err, ErrQuotaExhausted)
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_UnknownModeFallsBackToQueue(t *testing.T) {
+	l := New(Config{Mode: "panic"})
+	assert.Equal(t, ModeQueue, l.Mode())
+}
+
+func TestLimiter_StaleModeRejectsImmediately(t *testing.T) {
+	l := New(Config{Rate: 0.001, Burst: 1, Mode: ModeStale})
+
+	require.NoError(t, l.Acquire(context.Background()))
+	err := l.Acquire(context.Background())
+
+	assert.ErrorIs(t, err, ErrLimited)
+	assert.True(t, IsLimited(err))
+}
+
+func TestLimiter_QueueModeGivesUpAfterMaxWait(t *testing.T) {
+	l := New(Config{Rate: 0.001, Burst: 1, Mode: ModeQueue, MaxWait: 10 * time.Millisecond})
+
+	require.NoError(t, l.Acquire(context.Background()))
+	assert.ErrorIs(t, l.Acquire(context.Background()), ErrLimited)
+}
+
+func TestLimiter_QuotaExhausted(t *testing.T) {
+	l := New(Config{DailyQuota: 1, Mode: ModeFail})
+
+	require.NoError(t, l.Acquire(context.Background()))
+	err := l.Acquire(context.Background())
+
+	assert.ErrorIs(t, err, ErrQuotaExhausted)
+	assert.True(t, IsLimited(err))
+}
+
+func TestLimiter_Snapshot(t *testing.T) {
+	l := New(Config{Rate: 1, Burst: 5, DailyQuota: 10, Mode: ModeStale})
+	require.NoError(t, l.Acquire(context.Background()))
+
+	snap := l.Snapshot()
+
+	assert.Equal(t, ModeStale, snap.Mode)
+	assert.Equal(t, 1, snap.QuotaUsed)
+	assert.Equal(t, 10, snap.QuotaLimit)
+	assert.InDelta(t, 4, snap.Tokens, 0.1)
+}
+
+func TestDefaultConfig_KeepsQuotaInMemory(t *testing.T) {
+	assert.Empty(t, DefaultConfig().QuotaFile, "a default run must not write files into its working directory")
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"CountrySearch/internal/jsontree"
+	"encoding/csv"
+	"io"
+	"slices"
+	"strings"
+)
+
+// encodeCSV writes v as a table with a row per record. The records are v
+// itself if it is an array, else the first array of objects among its
+// fields, else v alone. Nested fields become dotted columns, and the
+// values of an array are joined with ";".
+func encodeCSV(w io.Writer, v any) error {
+	var rows [][]cell
+	var columns []string
+	for _, record := range records(v) {
+		row := flatten(record, "")
+		for _, c := range row {
+			if !slices.Contains(columns, c.column) {
+				columns = append(columns, c.column)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write(columns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		line := make([]string, len(columns))
+		for _, c := range row {
+			line[slices.Index(columns, c.column)] = c.value
+		}
+		if err := out.Write(line); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func records(v any) []any {
+	switch v := v.(type) {
+	case []any:
+		return v
+	case jsontree.Object:
+		for _, m := range v {
+			if items, ok := m.Value.([]any); ok && isTable(items) {
+				return items
+			}
+		}
+	}
+	return []any{v}
+}
+
+// isTable reports whether items are all objects. An empty array counts,
+// so an empty page is an empty table.
+func isTable(items []any) bool {
+	for _, item := range items {
+		if _, ok := item.(jsontree.Object); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+type cell struct {
+	column, value string
+}
+
+func flatten(v any, column string) []cell {
+	switch v := v.(type) {
+	case jsontree.Object:
+		var cells []cell
+		for _, m := range v {
+			cells = append(cells, flatten(m.Value, join(column, m.Key))...)
+		}
+		return cells
+	case []any:
+		var cells []cell
+		values := make(map[string][]string)
+		for _, item := range v {
+			for _, c := range flatten(item, column) {
+				if _, seen := values[c.column]; !seen {
+					cells = append(cells, cell{column: c.column})
+				}
+				values[c.column] = append(values[c.column], c.value)
+			}
+		}
+		for i := range cells {
+			cells[i].value = strings.Join(values[cells[i].column], ";")
+		}
+		return cells
+	}
+	return []cell{{column: column, value: scalar(v)}}
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,112 @@
+package render
+
+import (
+	"CountrySearch/internal/jsontree"
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// encodeMsgpack writes v in MessagePack, each value in its smallest form.
+func encodeMsgpack(w io.Writer, v any) error {
+	b := bufio.NewWriter(w)
+	if err := writeMsgpack(b, v); err != nil {
+		return err
+	}
+	return b.Flush()
+}
+
+// writeMsgpack returns an error for values MessagePack can't hold, and
+// leaves write errors to the writer, which keeps the first.
+func writeMsgpack(b *bufio.Writer, v any) error {
+	switch v := v.(type) {
+	case jsontree.Object:
+		writeHeader(b, len(v), 0x80, 16, 0xde, 0xdf)
+		for _, m := range v {
+			if err := writeMsgpack(b, m.Key); err != nil {
+				return err
+			}
+			if err := writeMsgpack(b, m.Value); err != nil {
+				return err
+			}
+		}
+	case []any:
+		writeHeader(b, len(v), 0x90, 16, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := writeMsgpack(b, item); err != nil {
+				return err
+			}
+		}
+	case string:
+		if len(v) <= math.MaxUint8 && len(v) >= 32 {
+			b.Write([]byte{0xd9, byte(len(v))})
+		} else {
+			writeHeader(b, len(v), 0xa0, 32, 0xda, 0xdb)
+		}
+		b.WriteString(v)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			writeInt(b, n)
+		} else if f, err := v.Float64(); err == nil {
+			b.WriteByte(0xcb)
+			b.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+		} else {
+			return fmt.Errorf("number %s does not fit MessagePack", v)
+		}
+	case bool:
+		if v {
+			b.WriteByte(0xc3)
+		} else {
+			b.WriteByte(0xc2)
+		}
+	default:
+		b.WriteByte(0xc0)
+	}
+	return nil
+}
+
+// writeHeader writes the length of a map, array or string: in the low
+// bits of fix when below fixLimit, else after the 16- or 32-bit marker.
+func writeHeader(b *bufio.Writer, n int, fix byte, fixLimit int, marker16, marker32 byte) {
+	switch {
+	case n < fixLimit:
+		b.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(marker16)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		b.WriteByte(marker32)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+func writeInt(b *bufio.Writer, n int64) {
+	switch {
+	case n >= 0 && n <= math.MaxInt8:
+		b.WriteByte(byte(n))
+	case n < 0 && n >= -32:
+		b.WriteByte(byte(int8(n)))
+	case n >= 0 && n <= math.MaxUint8:
+		b.Write([]byte{0xcc, byte(n)})
+	case n >= 0 && n <= math.MaxUint16:
+		b.WriteByte(0xcd)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n >= 0 && n <= math.MaxUint32:
+		b.WriteByte(0xce)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	case n >= math.MinInt8 && n < 0:
+		b.Write([]byte{0xd0, byte(int8(n))})
+	case n >= math.MinInt16 && n < 0:
+		b.WriteByte(0xd1)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(int16(n))))
+	case n >= math.MinInt32 && n < 0:
+		b.WriteByte(0xd2)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(n))))
+	default:
+		b.WriteByte(0xd3)
+		b.Write(binary.BigEndian.AppendUint64(nil, uint64(n)))
+	}
+}
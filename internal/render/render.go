@@ -0,0 +1,144 @@
+// Package render encodes JSON API responses in the format a client asks
+// for, by Accept header or format name.
+package render
+
+import (
+	"CountrySearch/internal/jsontree"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrNotAcceptable means no registered encoder produces what the client
+// accepts.
+var ErrNotAcceptable = errors.New("no acceptable response format")
+
+// Encoder writes a response, decoded by jsontree, in one format.
+type Encoder struct {
+	Format      string   // name for the format query parameter
+	ContentType string   // sent with every response
+	MediaTypes  []string // matched against Accept
+	Encode      func(w io.Writer, v any) error
+}
+
+// Transcode writes raw, a JSON response, in e's format.
+func (e Encoder) Transcode(w io.Writer, raw []byte) error {
+	if e.Format == JSON.Format {
+		_, err := w.Write(raw)
+		return err
+	}
+	v, err := jsontree.Decode(raw)
+	if err != nil {
+		return fmt.Errorf("decoding response to encode as %s: %w", e.Format, err)
+	}
+	return e.Encode(w, v)
+}
+
+// JSON is the default, for requests that don't ask for a format.
+var JSON = Encoder{
+	Format:      "json",
+	ContentType: "application/json",
+	MediaTypes:  []string{"application/json"},
+}
+
+// Encoders is the registry, in order of preference when a client accepts
+// several equally.
+var Encoders = []Encoder{
+	JSON,
+	{Format: "xml", ContentType: "application/xml; charset=utf-8", MediaTypes: []string{"application/xml", "text/xml"}, Encode: encodeXML},
+	{Format: "csv", ContentType: "text/csv; charset=utf-8", MediaTypes: []string{"text/csv"}, Encode: encodeCSV},
+	{Format: "yaml", ContentType: "application/yaml", MediaTypes: []string{"application/yaml", "application/x-yaml", "text/yaml"}, Encode: encodeYAML},
+	{Format: "msgpack", ContentType: "application/msgpack", MediaTypes: []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}, Encode: encodeMsgpack},
+}
+
+// Formats lists the format names, for error messages.
+func Formats() []string {
+	names := make([]string, len(Encoders))
+	for i, e := range Encoders {
+		names[i] = e.Format
+	}
+	return names
+}
+
+// Negotiate picks the encoder for a request. A format name wins over the
+// Accept header; with neither, the response is JSON. Otherwise the most
+// preferred type we can encode wins, a named type before a */* of the same
+// q, and a */* reached with nothing encodable listed before it is JSON.
+func Negotiate(format, accept string) (Encoder, error) {
+	if format != "" {
+		for _, e := range Encoders {
+			if strings.EqualFold(e.Format, format) {
+				return e, nil
+			}
+		}
+		return Encoder{}, fmt.Errorf("%w: unknown format %q", ErrNotAcceptable, format)
+	}
+	if strings.TrimSpace(accept) == "" {
+		return JSON, nil
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	refusesJSON := false
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+		} else {
+			refusesJSON = refusesJSON || slices.Contains(JSON.MediaTypes, mediaType)
+		}
+	}
+	slices.SortStableFunc(ranges, func(a, b mediaRange) int {
+		return cmp.Or(cmp.Compare(b.q, a.q), cmp.Compare(specificity(b.mediaType), specificity(a.mediaType)))
+	})
+
+	for _, r := range ranges {
+		for _, e := range Encoders {
+			if refusesJSON && e.Format == JSON.Format {
+				continue
+			}
+			if slices.ContainsFunc(e.MediaTypes, func(m string) bool { return matches(r.mediaType, m) }) {
+				return e, nil
+			}
+		}
+	}
+	return Encoder{}, fmt.Errorf("%w: %s", ErrNotAcceptable, accept)
+}
+
+// matches reports whether the media range r, which may be a wildcard,
+// covers mediaType.
+// specificity ranks a media range: */* below type/*, below a full type.
+func specificity(r string) int {
+	switch {
+	case r == "*/*":
+		return 0
+	case strings.HasSuffix(r, "/*"):
+		return 1
+	}
+	return 2
+}
+
+func matches(r, mediaType string) bool {
+	if r == "*/*" || r == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(r, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
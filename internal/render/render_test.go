@@ -0,0 +1,152 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		format, accept, want string
+	}{
+		{"", "", "json"},
+		{"", "*/*", "json"},
+		{"", "application/xml", "xml"},
+		{"", "text/xml;charset=utf-8", "xml"},
+		{"", "text/html, application/yaml;q=0.9", "yaml"},
+		{"", "text/html, application/yaml;q=0.9, */*;q=0.1", "yaml"},
+		{"", "text/html, */*;q=0.1", "json"},
+		{"", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "xml"},
+		{"", "application/msgpack, */*", "msgpack"},
+		{"", "*/*, application/msgpack", "msgpack"},
+		{"", "text/csv, */*;q=0.5", "csv"},
+		{"", "application/json;q=0, application/xml, */*", "xml"},
+		{"", "application/json;q=0, */*", "xml"},
+		{"", "application/json;q=0.5, text/csv", "csv"},
+		{"", "application/x-msgpack", "msgpack"},
+		{"", "text/*", "xml"},
+		{"", "application/*", "json"},
+		{"", "not a type, application/xml", "xml"},
+		{"YAML", "application/xml", "yaml"},
+	}
+	for _, tt := range tests {
+		e, err := Negotiate(tt.format, tt.accept)
+		require.NoError(t, err, tt)
+		assert.Equal(t, tt.want, e.Format, tt)
+	}
+}
+
+func TestNegotiate_NotAcceptable(t *testing.T) {
+	for _, tt := range []struct{ format, accept string }{
+		{"", "text/html"},
+		{"", "application/json;q=0"},
+		{"toml", ""},
+	} {
+		_, err := Negotiate(tt.format, tt.accept)
+		assert.ErrorIs(t, err, ErrNotAcceptable, tt)
+	}
+}
+
+const page = `{"countries":[` +
+	`{"name":"Panama","population":4300000,"area":75417.5,"currencies":[{"code":"PAB"},{"code":"USD"}],"flags":{"png":"pa.png"},"landlocked":false},` +
+	`{"name":"Côte d'Ivoire & co","population":1,"area":0.5,"currencies":[],"flags":{},"landlocked":null}` +
+	`],"total":2}`
+
+func transcode(t *testing.T, format, raw string) string {
+	t.Helper()
+	e, err := Negotiate(format, "")
+	require.NoError(t, err)
+	var b bytes.Buffer
+	require.NoError(t, e.Transcode(&b, []byte(raw)))
+	return b.String()
+}
+
+func TestTranscode_JSONPassesThrough(t *testing.T) {
+	assert.Equal(t, page, transcode(t, "json", page))
+}
+
+func TestTranscode_XML(t *testing.T) {
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><countries>`+
+		`<item><name>Panama</name><population>4300000</population><area>75417.5</area>`+
+		`<currencies><item><code>PAB</code></item><item><code>USD</code></item></currencies>`+
+		`<flags><png>pa.png</png></flags><landlocked>false</landlocked></item>`+
+		`<item><name>Côte d&#39;Ivoire &amp; co</name><population>1</population><area>0.5</area>`+
+		`<currencies></currencies><flags></flags><landlocked></landlocked></item>`+
+		`</countries><total>2</total></response>`, transcode(t, "xml", page))
+}
+
+func TestTranscode_CSV(t *testing.T) {
+	assert.Equal(t, "name,population,area,currencies.code,flags.png,landlocked\n"+
+		"Panama,4300000,75417.5,PAB;USD,pa.png,false\n"+
+		"Côte d'Ivoire & co,1,0.5,,,\n", transcode(t, "csv", page))
+
+	assert.Equal(t, "name,capital\nPanama,Panama City\n", transcode(t, "csv", `{"name":"Panama","capital":"Panama City"}`),
+		"a single record is one row")
+	assert.Equal(t, "\n", transcode(t, "csv", `{"countries":[],"total":0}`), "an empty page has no columns")
+}
+
+func TestTranscode_YAML(t *testing.T) {
+	assert.Equal(t, `countries:
+  - name: Panama
+    population: 4300000
+    area: 75417.5
+    currencies:
+      - code: PAB
+      - code: USD
+    flags:
+      png: pa.png
+    landlocked: false
+  - name: Côte d'Ivoire & co
+    population: 1
+    area: 0.5
+    currencies: []
+    flags: {}
+    landlocked: null
+total: 2
+`, transcode(t, "yaml", page))
+
+	assert.Equal(t, "numeric: \"578\"\nempty: \"\"\n", transcode(t, "yaml", `{"numeric":"578","empty":""}`),
+		"strings that would read as other types are quoted")
+}
+
+func TestTranscode_Msgpack(t *testing.T) {
+	got := transcode(t, "msgpack", `{"a":[1,-1,200,-200,70000,1.5,true,null],"s":"hi"}`)
+
+	assert.Equal(t, []byte{
+		0x82,
+		0xa1, 'a', 0x98,
+		0x01,
+		0xff,
+		0xcc, 0xc8,
+		0xd1, 0xff, 0x38,
+		0xce, 0x00, 0x01, 0x11, 0x70,
+		0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0,
+		0xc3,
+		0xc0,
+		0xa1, 's', 0xa2, 'h', 'i',
+	}, []byte(got))
+}
+
+func TestTranscode_MsgpackRejectsNumbersTooLarge(t *testing.T) {
+	e, err := Negotiate("msgpack", "")
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	err = e.Transcode(&b, []byte(`{"a":1e400}`))
+
+	assert.EqualError(t, err, "number 1e400 does not fit MessagePack")
+}
+
+func TestTranscode_MsgpackLengths(t *testing.T) {
+	long := bytes.Repeat([]byte("x"), 40)
+	got := transcode(t, "msgpack", `"`+string(long)+`"`)
+	assert.Equal(t, append([]byte{0xd9, 40}, long...), []byte(got))
+
+	items := bytes.Repeat([]byte("0,"), 20)
+	got = transcode(t, "msgpack", "["+string(items)+"0]")
+	assert.Equal(t, append([]byte{0xdc, 0, 21}, bytes.Repeat([]byte{0}, 21)...), []byte(got))
+}
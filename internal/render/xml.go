@@ -0,0 +1,62 @@
+package render
+
+import (
+	"CountrySearch/internal/jsontree"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// encodeXML writes v as a <response> element. Object fields become child
+// elements of the same name and array items <item> elements.
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err := writeXML(enc, "response", v); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func writeXML(enc *xml.Encoder, name string, v any) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case jsontree.Object:
+		for _, m := range v {
+			if err := writeXML(enc, m.Key, m.Value); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := writeXML(enc, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(scalar(v))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// scalar formats a JSON string, number or boolean as text.
+func scalar(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
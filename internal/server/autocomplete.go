@@ -0,0 +1,69 @@
+package server
+
+import (
+	"CountrySearch/internal/index"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultCompletions = 5
+
+type completion struct {
+	Name       string `json:"name"`
+	Matched    string `json:"matched"`
+	Alpha2Code string `json:"alpha2_code"`
+	Alpha3Code string `json:"alpha3_code"`
+	Population int    `json:"population"`
+}
+
+type autocompleteResponse struct {
+	Query       string       `json:"query"`
+	Suggestions []completion `json:"suggestions"`
+}
+
+// AutocompleteHandler suggests countries as a name is typed, most populous
+// first, from names, native names and aliases starting with q. It answers
+// from the synced index, or the cached full list while none is loaded, so
+// keystrokes don't reach the provider.
+func (s *Server) AutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+	fields, ok := parseFields(w, r, completionType)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	prefix := strings.TrimSpace(q.Get("q"))
+	if prefix == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	limit := defaultCompletions
+	if v := q.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > index.MaxCompletions {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", index.MaxCompletions), http.StatusBadRequest)
+			return
+		}
+	}
+
+	idx, err := s.countryIndex(r.Context())
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+
+	resp := autocompleteResponse{Query: prefix, Suggestions: []completion{}}
+	for _, c := range idx.Complete(prefix, limit) {
+		resp.Suggestions = append(resp.Suggestions, completion{
+			Name:       c.Country.Name,
+			Matched:    c.Matched,
+			Alpha2Code: c.Country.Alpha2Code,
+			Alpha3Code: c.Country.Alpha3Code,
+			Population: c.Country.Population,
+		})
+	}
+	writeFields(w, resp, fields, "suggestions")
+}
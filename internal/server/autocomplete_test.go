@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutocompleteHandler(t *testing.T) {
+	s, stub := syncedServer(t, testCountries...)
+
+	rr, resp := fetch[autocompleteResponse](t, s, "GET", "/api/countries/autocomplete?q=nig", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "nig", resp.Query)
+	require.Len(t, resp.Suggestions, 2)
+	assert.Equal(t, completion{Name: "Nigeria", Matched: "Nigeria", Alpha2Code: "NG", Alpha3Code: "NGA", Population: 218000000}, resp.Suggestions[0])
+	assert.Equal(t, "Niger", resp.Suggestions[1].Name)
+	assert.Zero(t, stub.calls)
+}
+
+func TestAutocompleteHandler_MatchesNativeNamesAndLimits(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	_, resp := fetch[autocompleteResponse](t, s, "GET", "/api/countries/autocomplete?q=Deut", "")
+	require.Len(t, resp.Suggestions, 1)
+	assert.Equal(t, "Germany", resp.Suggestions[0].Name)
+	assert.Equal(t, "Deutschland", resp.Suggestions[0].Matched)
+
+	_, resp = fetch[autocompleteResponse](t, s, "GET", "/api/countries/autocomplete?q=ge&limit=1", "")
+	require.Len(t, resp.Suggestions, 1)
+	assert.Equal(t, "Germany", resp.Suggestions[0].Name)
+
+	rr, resp := fetch[autocompleteResponse](t, s, "GET", "/api/countries/autocomplete?q=zz", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotNil(t, resp.Suggestions)
+	assert.Empty(t, resp.Suggestions)
+}
+
+func TestAutocompleteHandler_ListFetchedOnce(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: testCountries}
+	s.provider = stub
+
+	for _, q := range []string{"g", "ge", "ger"} {
+		rr := serve(t, s, "GET", "/api/countries/autocomplete?q="+q, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+	assert.Equal(t, 1, stub.calls)
+}
+
+func TestAutocompleteHandler_BadRequests(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	for _, target := range []string{
+		"/api/countries/autocomplete",
+		"/api/countries/autocomplete?q=%20",
+		"/api/countries/autocomplete?q=ge&limit=0",
+		"/api/countries/autocomplete?q=ge&limit=21",
+	} {
+		rr := serve(t, s, "GET", target, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+	}
+}
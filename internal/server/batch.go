@@ -0,0 +1,164 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/index"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultBatchMaxItems = 1000
+	defaultBatchWorkers  = 8
+	// maxBatchItemBytes bounds the request body per allowed item.
+	maxBatchItemBytes = 256
+)
+
+type batchResult struct {
+	Query   string               `json:"query"`
+	Country *externalapi.Country `json:"country,omitempty"`
+	Status  int                  `json:"status"`
+	Error   string               `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Results []batchResult `json:"results"`
+}
+
+// BatchLookupHandler resolves a JSON array of country names and ISO 3166
+// codes against the country index, so a batch costs at most one upstream
+// call. Only when the full list can't be fetched are items looked up one
+// by one, through the cache, by a bounded pool of workers. Each item gets
+// its own result and status, in input order.
+func (s *Server) BatchLookupHandler(w http.ResponseWriter, r *http.Request) {
+	fields, ok := parseFields(w, r, countryType)
+	if !ok {
+		return
+	}
+
+	maxItems := positiveOr(s.batchMaxItems, defaultBatchMaxItems)
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxItems)*maxBatchItemBytes)
+
+	var queries []string
+	if err := json.NewDecoder(r.Body).Decode(&queries); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("Batch may hold at most %d items", maxItems), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Request body must be a JSON array of names or codes", http.StatusBadRequest)
+		return
+	}
+	if len(queries) > maxItems {
+		http.Error(w, fmt.Sprintf("Batch may hold at most %d items", maxItems), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Repeated queries are resolved once; concurrent misses on one cache
+	// key would each reach the provider.
+	results := make([]batchResult, len(queries))
+	first := make(map[string]int, len(queries))
+	var unique []int
+	for i, q := range queries {
+		if _, seen := first[cacheKey(q)]; !seen {
+			first[cacheKey(q)] = i
+			unique = append(unique, i)
+		}
+	}
+
+	if len(unique) > 0 {
+		resolve := s.countryResolver(r.Context())
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for range min(positiveOr(s.batchWorkers, defaultBatchWorkers), len(unique)) {
+			wg.Go(func() {
+				for i := range jobs {
+					results[i] = resolveBatchItem(r.Context(), resolve, queries[i])
+				}
+			})
+		}
+		for _, i := range unique {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	for i, q := range queries {
+		if j := first[cacheKey(q)]; j != i {
+			results[i] = results[j]
+			results[i].Query = q
+		}
+	}
+
+	writeFields(w, batchResponse{Results: results}, fields, "results", "country")
+}
+
+// countryResolver returns how the names and codes of a batch or an upload
+// are resolved: against the country index, or with a lookup by name each
+// when the index can't be loaded for a reason single lookups may not
+// share, such as the full list being too large. Codes are only known to
+// the index, and looking them up would load it again for every item.
+func (s *Server) countryResolver(ctx context.Context) func(context.Context, string) (externalapi.Country, error) {
+	idx, err := s.countryIndex(ctx)
+	switch {
+	case err == nil:
+		return func(_ context.Context, query string) (externalapi.Country, error) {
+			if country, ok := findCountry(idx, query); ok {
+				return country, nil
+			}
+			return externalapi.Country{}, externalapi.ErrCountryNotFound
+		}
+	case errors.Is(err, externalapi.ErrUnavailable):
+		return func(context.Context, string) (externalapi.Country, error) {
+			return externalapi.Country{}, err
+		}
+	}
+	log.Printf("error loading the country index, looking countries up by name: %v", err)
+	return s.lookupCountry
+}
+
+func resolveBatchItem(ctx context.Context, resolve func(context.Context, string) (externalapi.Country, error), query string) batchResult {
+	result := batchResult{Query: query}
+	if strings.TrimSpace(query) == "" {
+		result.Status = http.StatusBadRequest
+		result.Error = "Country name cannot be empty"
+		return result
+	}
+
+	country, err := resolve(ctx, query)
+	if err != nil {
+		result.Status, result.Error = lookupStatus(err)
+		return result
+	}
+
+	result.Status = http.StatusOK
+	result.Country = &country
+	return result
+}
+
+// resolveCountry looks query up as an ISO 3166 code when it could be one,
+// and by name otherwise or when no country holds the code.
+func (s *Server) resolveCountry(ctx context.Context, query string) (externalapi.Country, error) {
+	if code, err := index.ParseCode(query); err == nil {
+		country, err := s.lookupByCode(ctx, code)
+		if !errors.Is(err, externalapi.ErrCountryNotFound) {
+			return country, err
+		}
+	}
+	return s.lookupCountry(ctx, query)
+}
+
+// positiveOr returns v, or def when v is not positive.
+func positiveOr(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
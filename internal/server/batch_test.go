@@ -0,0 +1,167 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchLookupHandler_ResolvesNamesAndCodesInOrder(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr, resp := fetch[batchResponse](t, s, "POST", "/api/countries/batch", `["australia", "FR", "Atlantis", "036", "", "XK"]`)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Len(t, resp.Results, 6)
+
+	assert.Equal(t, "australia", resp.Results[0].Query)
+	assert.Equal(t, "Canberra", resp.Results[0].Country.Capital)
+	assert.Equal(t, "Paris", resp.Results[1].Country.Capital)
+	assert.Equal(t, "Canberra", resp.Results[3].Country.Capital)
+
+	assert.Equal(t, http.StatusNotFound, resp.Results[2].Status)
+	assert.Equal(t, "Country not found", resp.Results[2].Error)
+	assert.Nil(t, resp.Results[2].Country)
+	assert.Equal(t, http.StatusBadRequest, resp.Results[4].Status)
+	assert.Equal(t, http.StatusNotFound, resp.Results[5].Status)
+}
+
+func TestBatchLookupHandler_ReportsUnavailablePerItem(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{err: fmt.Errorf("stub: %w", externalapi.ErrUnavailable)}
+
+	_, resp := fetch[batchResponse](t, s, "POST", "/api/countries/batch", `["France"]`)
+
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Results[0].Status)
+}
+
+func TestBatchLookupHandler_RejectsBadBodies(t *testing.T) {
+	s := setupTestServer()
+	s.batchMaxItems = 2
+
+	for body, status := range map[string]int{
+		`{"names": ["France"]}`:                 http.StatusBadRequest,
+		`[1, 2]`:                                http.StatusBadRequest,
+		`not json`:                              http.StatusBadRequest,
+		`["a", "b", "c"]`:                       http.StatusRequestEntityTooLarge,
+		`["` + strings.Repeat("x", 1000) + `"]`: http.StatusRequestEntityTooLarge,
+	} {
+		rr := serve(t, s, "POST", "/api/countries/batch", body)
+		assert.Equal(t, status, rr.Code, body)
+	}
+}
+
+func TestBatchLookupHandler_EmptyBatch(t *testing.T) {
+	s := setupTestServer()
+
+	rr := serve(t, s, "POST", "/api/countries/batch", `[]`)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"results":[]}`, rr.Body.String())
+}
+
+// slowProvider answers every search after a delay, tracking how many
+// searches run at once.
+type slowProvider struct {
+	delay           time.Duration
+	calls, inFlight atomic.Int32
+	maxInFlight     atomic.Int32
+	listCalls       atomic.Int32
+}
+
+func (p *slowProvider) Name() string { return "slow" }
+
+func (p *slowProvider) SearchCountries(ctx context.Context, name string) ([]externalapi.Country, error) {
+	p.calls.Add(1)
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		m := p.maxInFlight.Load()
+		if n <= m || p.maxInFlight.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	time.Sleep(p.delay)
+	return []externalapi.Country{{Name: name}}, nil
+}
+
+func (p *slowProvider) ListCountries(ctx context.Context) ([]externalapi.Country, error) {
+	p.listCalls.Add(1)
+	return nil, errors.New("not used")
+}
+
+func TestBatchLookupHandler_ResolvesAgainstFullListUntilSynced(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: testCountries}
+	s.provider = stub
+
+	_, resp := fetch[batchResponse](t, s, "POST", "/api/countries/batch", `["France", "DE", "Deutschland", "036", "Atlantis"]`)
+
+	require.Len(t, resp.Results, 5)
+	for i, want := range []string{"France", "Germany", "Germany", "Australia"} {
+		require.Equal(t, http.StatusOK, resp.Results[i].Status, resp.Results[i].Query)
+		assert.Equal(t, want, resp.Results[i].Country.Name)
+	}
+	assert.Equal(t, http.StatusNotFound, resp.Results[4].Status)
+	assert.Equal(t, 1, stub.calls, "one list, not a search per item")
+}
+
+func TestBatchLookupHandler_ListsOnceWhenTheIndexFails(t *testing.T) {
+	s := setupTestServer()
+	provider := &slowProvider{}
+	s.provider = provider
+
+	_, resp := fetch[batchResponse](t, s, "POST", "/api/countries/batch", `["FR", "DE", "ES", "036", "CHL", "France"]`)
+
+	require.Len(t, resp.Results, 6)
+	for _, result := range resp.Results {
+		assert.Equal(t, http.StatusOK, result.Status, result.Query)
+	}
+	assert.Equal(t, int32(1), provider.listCalls.Load(), "codes are looked up by name, not against a fresh list each")
+	assert.Equal(t, int32(6), provider.calls.Load())
+}
+
+func TestBatchLookupHandler_BoundsConcurrency(t *testing.T) {
+	// slowProvider can't list its countries, so each item is looked up.
+	s := setupTestServer()
+	provider := &slowProvider{delay: 10 * time.Millisecond}
+	s.provider = provider
+	s.batchWorkers = 3
+
+	var names []string
+	for i := range 12 {
+		names = append(names, fmt.Sprintf("Country %d", i))
+	}
+	body, _ := json.Marshal(names)
+	_, resp := fetch[batchResponse](t, s, "POST", "/api/countries/batch", string(body))
+
+	require.Len(t, resp.Results, 12)
+	for i, r := range resp.Results {
+		assert.Equal(t, names[i], r.Country.Name)
+	}
+	assert.LessOrEqual(t, provider.maxInFlight.Load(), int32(3))
+}
+
+func TestBatchLookupHandler_ResolvesRepeatsOnce(t *testing.T) {
+	s := setupTestServer()
+	provider := &slowProvider{}
+	s.provider = provider
+
+	_, resp := fetch[batchResponse](t, s, "POST", "/api/countries/batch", `["Chile", "chile ", "Chile"]`)
+
+	require.Len(t, resp.Results, 3)
+	assert.Equal(t, "chile ", resp.Results[1].Query)
+	assert.Equal(t, "Chile", resp.Results[1].Country.Name)
+	assert.Equal(t, int32(1), provider.calls.Load())
+}
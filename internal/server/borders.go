@@ -0,0 +1,172 @@
+package server
+
+import (
+	"CountrySearch/internal/borders"
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/index"
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const maxNeighbourDepth = 5
+
+// indexedGraph is the border graph built from one index, kept until the
+// index is replaced by a resync or expires.
+type indexedGraph struct {
+	idx   *index.Index
+	graph *borders.Graph
+}
+
+// borderGraph returns the country index with the border graph built
+// from it.
+func (s *Server) borderGraph(ctx context.Context) (*index.Index, *borders.Graph, error) {
+	idx, err := s.countryIndex(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if g := s.borders.Load(); g != nil && g.idx == idx {
+		return idx, g.graph, nil
+	}
+	g := &indexedGraph{idx: idx, graph: borders.New(idx.All())}
+	s.borders.Store(g)
+	return idx, g.graph, nil
+}
+
+// findCountry resolves query against idx as an ISO 3166 code when it could
+// be one, and by name otherwise or when no country holds the code.
+func findCountry(idx *index.Index, query string) (externalapi.Country, bool) {
+	if code, err := index.ParseCode(query); err == nil {
+		if c, ok := idx.ByCode(code); ok {
+			return c, true
+		}
+	}
+	return idx.Lookup(query)
+}
+
+type countryRef struct {
+	Alpha3Code string `json:"alpha3_code"`
+	Name       string `json:"name"`
+}
+
+func refOf(c externalapi.Country) countryRef {
+	return countryRef{Alpha3Code: c.Alpha3Code, Name: c.Name}
+}
+
+type neighbour struct {
+	countryRef
+	Distance int `json:"distance"`
+}
+
+type neighboursResponse struct {
+	Country    countryRef  `json:"country"`
+	Depth      int         `json:"depth"`
+	Neighbours []neighbour `json:"neighbours"`
+}
+
+// NeighboursHandler lists the countries within depth land border
+// crossings of the country named or coded in the path, nearest first.
+func (s *Server) NeighboursHandler(w http.ResponseWriter, r *http.Request) {
+	fields, ok := parseFields(w, r, neighbourType)
+	if !ok {
+		return
+	}
+
+	depth := 1
+	if v := r.URL.Query().Get("depth"); v != "" {
+		var err error
+		depth, err = strconv.Atoi(v)
+		if err != nil || depth < 1 || depth > maxNeighbourDepth {
+			http.Error(w, fmt.Sprintf("depth must be between 1 and %d", maxNeighbourDepth), http.StatusBadRequest)
+			return
+		}
+	}
+
+	idx, graph, err := s.borderGraph(r.Context())
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	country, ok := findCountry(idx, r.PathValue("code"))
+	if !ok {
+		http.Error(w, "Country not found", http.StatusNotFound)
+		return
+	}
+
+	resp := neighboursResponse{Country: refOf(country), Depth: depth, Neighbours: []neighbour{}}
+	for _, n := range graph.Neighbours(country.Alpha3Code, depth) {
+		c, _ := idx.ByCode(n.Code)
+		resp.Neighbours = append(resp.Neighbours, neighbour{countryRef: refOf(c), Distance: n.Distance})
+	}
+	slices.SortStableFunc(resp.Neighbours, func(a, b neighbour) int {
+		return cmp.Or(cmp.Compare(a.Distance, b.Distance), strings.Compare(a.Name, b.Name))
+	})
+	writeFields(w, resp, fields, "neighbours")
+}
+
+type routeResponse struct {
+	From      countryRef   `json:"from"`
+	To        countryRef   `json:"to"`
+	Reachable bool         `json:"reachable"`
+	Crossings *int         `json:"crossings,omitempty"`
+	Path      []countryRef `json:"path,omitempty"`
+	Message   string       `json:"message,omitempty"`
+}
+
+// RouteHandler finds the land route between two countries that crosses
+// the fewest borders. Countries with no land route between them, such as
+// an island and the mainland, are reported as unreachable rather than as
+// an error.
+func (s *Server) RouteHandler(w http.ResponseWriter, r *http.Request) {
+	fields, ok := parseFields(w, r, countryRefType)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	fromQuery, toQuery := strings.TrimSpace(q.Get("from")), strings.TrimSpace(q.Get("to"))
+	if fromQuery == "" || toQuery == "" {
+		http.Error(w, "from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	idx, graph, err := s.borderGraph(r.Context())
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	var missing []string
+	from, ok := findCountry(idx, fromQuery)
+	if !ok {
+		missing = append(missing, fromQuery)
+	}
+	to, ok := findCountry(idx, toQuery)
+	if !ok {
+		missing = append(missing, toQuery)
+	}
+	if len(missing) > 0 {
+		http.Error(w, "Country not found: "+strings.Join(missing, ", "), http.StatusNotFound)
+		return
+	}
+
+	resp := routeResponse{From: refOf(from), To: refOf(to)}
+	codes, ok := graph.Route(from.Alpha3Code, to.Alpha3Code)
+	if !ok {
+		resp.Message = fmt.Sprintf("No land route from %s to %s", from.Name, to.Name)
+		writeFields(w, resp, fields, "path")
+		return
+	}
+
+	crossings := len(codes) - 1
+	resp.Reachable = true
+	resp.Crossings = &crossings
+	for _, code := range codes {
+		c, _ := idx.ByCode(code)
+		resp.Path = append(resp.Path, refOf(c))
+	}
+	writeFields(w, resp, fields, "path")
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeighboursHandler(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr, resp := fetch[neighboursResponse](t, s, "GET", "/api/countries/pt/neighbours?depth=2", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, countryRef{Alpha3Code: "PRT", Name: "Portugal"}, resp.Country)
+	assert.Equal(t, 2, resp.Depth)
+	assert.Equal(t, []neighbour{
+		{countryRef: countryRef{Alpha3Code: "ESP", Name: "Spain"}, Distance: 1},
+		{countryRef: countryRef{Alpha3Code: "AND", Name: "Andorra"}, Distance: 2},
+		{countryRef: countryRef{Alpha3Code: "FRA", Name: "France"}, Distance: 2},
+	}, resp.Neighbours)
+}
+
+func TestNeighboursHandler_ByNameWithDefaultDepth(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr, resp := fetch[neighboursResponse](t, s, "GET", "/api/countries/Germany/neighbours", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 1, resp.Depth)
+	assert.Len(t, resp.Neighbours, 4)
+
+	rr, resp = fetch[neighboursResponse](t, s, "GET", "/api/countries/ISL/neighbours", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotNil(t, resp.Neighbours)
+	assert.Empty(t, resp.Neighbours)
+}
+
+func TestNeighboursHandler_Errors(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	for target, want := range map[string]int{
+		"/api/countries/PT/neighbours?depth=0": http.StatusBadRequest,
+		"/api/countries/PT/neighbours?depth=6": http.StatusBadRequest,
+		"/api/countries/PT/neighbours?depth=x": http.StatusBadRequest,
+		"/api/countries/XYZ/neighbours":        http.StatusNotFound,
+		"/api/countries/Atlantis/neighbours":   http.StatusNotFound,
+	} {
+		rr := serve(t, s, "GET", target, "")
+		assert.Equal(t, want, rr.Code, target)
+	}
+}
+
+func TestRouteHandler(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr, resp := fetch[routeResponse](t, s, "GET", "/api/routes?from=PT&to=CN", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, resp.Reachable)
+	require.NotNil(t, resp.Crossings)
+	assert.Equal(t, 6, *resp.Crossings)
+	var path []string
+	for _, c := range resp.Path {
+		path = append(path, c.Alpha3Code)
+	}
+	assert.Equal(t, []string{"PRT", "ESP", "FRA", "DEU", "POL", "RUS", "CHN"}, path)
+}
+
+func TestRouteHandler_SameCountry(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr, resp := fetch[routeResponse](t, s, "GET", "/api/routes?from=France&to=FRA", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, resp.Reachable)
+	assert.Equal(t, 0, *resp.Crossings)
+	assert.Len(t, resp.Path, 1)
+}
+
+func TestRouteHandler_NoLandRoute(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr, resp := fetch[routeResponse](t, s, "GET", "/api/routes?from=PT&to=Iceland", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.False(t, resp.Reachable)
+	assert.Nil(t, resp.Crossings)
+	assert.Empty(t, resp.Path)
+	assert.Equal(t, "No land route from Portugal to Iceland", resp.Message)
+}
+
+func TestRouteHandler_Errors(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "GET", "/api/routes?from=PT", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(t, s, "GET", "/api/routes?from=Atlantis&to=Lemuria", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Atlantis, Lemuria")
+}
+
+func TestBorderGraph_RebuiltOnlyForNewIndex(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	_, first, err := s.borderGraph(t.Context())
+	require.NoError(t, err)
+	_, again, err := s.borderGraph(t.Context())
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+
+	require.NoError(t, s.syncer.Sync(t.Context()))
+	_, resynced, err := s.borderGraph(t.Context())
+	require.NoError(t, err)
+	assert.NotSame(t, first, resynced)
+}
+
+func TestBorderGraph_Unavailable(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{err: externalapi.ErrUnavailable}
+
+	rr := serve(t, s, "GET", "/api/routes?from=PT&to=CN", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const maxCompared = 10
+
+// countryMetrics are one country's figures and where they rank among the
+// compared countries, 1 being the largest.
+type countryMetrics struct {
+	Name           string   `json:"name"`
+	Alpha3Code     string   `json:"alpha3_code"`
+	Population     int      `json:"population"`
+	Area           float64  `json:"area"`
+	Density        *float64 `json:"density,omitempty"` // people per km², unknown without an area
+	PopulationRank int      `json:"population_rank"`
+	AreaRank       int      `json:"area_rank"`
+	DensityRank    int      `json:"density_rank,omitempty"`
+}
+
+// pairComparison compares A to B; ratios are A's figure over B's and are
+// left out when B's is zero.
+type pairComparison struct {
+	A                string   `json:"a"`
+	B                string   `json:"b"`
+	PopulationRatio  *float64 `json:"population_ratio,omitempty"`
+	AreaRatio        *float64 `json:"area_ratio,omitempty"`
+	DensityRatio     *float64 `json:"density_ratio,omitempty"`
+	Neighbours       bool     `json:"neighbours"`
+	SharedCurrencies []string `json:"shared_currencies"`
+	SharedLanguages  []string `json:"shared_languages"`
+	SharedBorders    []string `json:"shared_borders"`
+}
+
+// sharedByAll lists what every compared country has in common.
+type sharedByAll struct {
+	Currencies []string `json:"currencies"`
+	Languages  []string `json:"languages"`
+	Borders    []string `json:"borders"`
+}
+
+type compareResponse struct {
+	Countries []externalapi.Country `json:"countries"`
+	Metrics   []countryMetrics      `json:"metrics"`
+	Pairs     []pairComparison      `json:"pairs"`
+	Shared    sharedByAll           `json:"shared"`
+}
+
+// CompareHandler looks up the comma-separated names (or codes) and
+// compares them side by side.
+func (s *Server) CompareHandler(w http.ResponseWriter, r *http.Request) {
+	fields, ok := parseFields(w, r, countryType)
+	if !ok {
+		return
+	}
+
+	var queries []string
+	for _, q := range strings.Split(r.URL.Query().Get("names"), ",") {
+		if q = strings.TrimSpace(q); q != "" {
+			queries = append(queries, q)
+		}
+	}
+	if len(queries) < 2 || len(queries) > maxCompared {
+		http.Error(w, fmt.Sprintf("names must list between 2 and %d countries", maxCompared), http.StatusBadRequest)
+		return
+	}
+
+	countries := make([]externalapi.Country, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Go(func() {
+			countries[i], errs[i] = s.resolveCountry(r.Context(), q)
+		})
+	}
+	wg.Wait()
+
+	var missing []string
+	for i, err := range errs {
+		switch {
+		case errors.Is(err, externalapi.ErrCountryNotFound):
+			missing = append(missing, queries[i])
+		case err != nil:
+			writeLookupError(w, err)
+			return
+		}
+	}
+	if len(missing) > 0 {
+		http.Error(w, "Country not found: "+strings.Join(missing, ", "), http.StatusNotFound)
+		return
+	}
+
+	writeFields(w, compareCountries(countries), fields, "countries")
+}
+
+func compareCountries(countries []externalapi.Country) compareResponse {
+	resp := compareResponse{Countries: countries}
+
+	metrics := make([]countryMetrics, len(countries))
+	for i, c := range countries {
+		metrics[i] = countryMetrics{
+			Name:       c.Name,
+			Alpha3Code: c.Alpha3Code,
+			Population: c.Population,
+			Area:       c.Area,
+			Density:    ratio(float64(c.Population), c.Area),
+		}
+	}
+	rank(metrics, func(m countryMetrics) (float64, bool) { return float64(m.Population), true },
+		func(m *countryMetrics, r int) { m.PopulationRank = r })
+	rank(metrics, func(m countryMetrics) (float64, bool) { return m.Area, true },
+		func(m *countryMetrics, r int) { m.AreaRank = r })
+	rank(metrics, func(m countryMetrics) (float64, bool) {
+		if m.Density == nil {
+			return 0, false
+		}
+		return *m.Density, true
+	}, func(m *countryMetrics, r int) { m.DensityRank = r })
+	resp.Metrics = metrics
+
+	for i, a := range countries {
+		for j := i + 1; j < len(countries); j++ {
+			b := countries[j]
+			pair := pairComparison{
+				A:                a.Alpha3Code,
+				B:                b.Alpha3Code,
+				PopulationRatio:  ratio(float64(a.Population), float64(b.Population)),
+				AreaRatio:        ratio(a.Area, b.Area),
+				Neighbours:       slices.Contains(a.Borders, b.Alpha3Code) || slices.Contains(b.Borders, a.Alpha3Code),
+				SharedCurrencies: intersect(currencyCodes(a), currencyCodes(b)),
+				SharedLanguages:  intersect(languageNames(a), languageNames(b)),
+				SharedBorders:    intersect(a.Borders, b.Borders),
+			}
+			if metrics[i].Density != nil && metrics[j].Density != nil {
+				pair.DensityRatio = ratio(*metrics[i].Density, *metrics[j].Density)
+			}
+			resp.Pairs = append(resp.Pairs, pair)
+		}
+	}
+
+	resp.Shared = sharedByAll{
+		Currencies: currencyCodes(countries[0]),
+		Languages:  languageNames(countries[0]),
+		Borders:    slices.Clone(countries[0].Borders),
+	}
+	for _, c := range countries[1:] {
+		resp.Shared.Currencies = intersect(resp.Shared.Currencies, currencyCodes(c))
+		resp.Shared.Languages = intersect(resp.Shared.Languages, languageNames(c))
+		resp.Shared.Borders = intersect(resp.Shared.Borders, c.Borders)
+	}
+	return resp
+}
+
+// rank sets each metric's rank by value, largest first. Metrics without a
+// value are left unranked; equal values share a rank.
+func rank(metrics []countryMetrics, value func(countryMetrics) (float64, bool), set func(*countryMetrics, int)) {
+	var order []int
+	for i, m := range metrics {
+		if _, ok := value(m); ok {
+			order = append(order, i)
+		}
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		va, _ := value(metrics[a])
+		vb, _ := value(metrics[b])
+		return cmp.Compare(vb, va)
+	})
+	var prevRank int
+	var prevValue float64
+	for pos, i := range order {
+		v, _ := value(metrics[i])
+		r := pos + 1
+		if pos > 0 && v == prevValue {
+			r = prevRank
+		}
+		set(&metrics[i], r)
+		prevRank, prevValue = r, v
+	}
+}
+
+func ratio(a, b float64) *float64 {
+	if b == 0 {
+		return nil
+	}
+	r := math.Round(a/b*1000) / 1000
+	return &r
+}
+
+func currencyCodes(c externalapi.Country) []string {
+	var codes []string
+	for _, cur := range c.Currencies {
+		if cur.Code != "" {
+			codes = append(codes, cur.Code)
+		}
+	}
+	return codes
+}
+
+func languageNames(c externalapi.Country) []string {
+	var names []string
+	for _, l := range c.Languages {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// intersect returns the items of a also in b, in a's order, never nil.
+func intersect(a, b []string) []string {
+	out := []string{}
+	for _, v := range a {
+		if slices.Contains(b, v) && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
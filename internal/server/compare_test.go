@@ -0,0 +1,128 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareHandler_TwoCountries(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr, resp := fetch[compareResponse](t, s, "GET", "/api/countries/compare?names=France,%20germany", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Len(t, resp.Countries, 2)
+	assert.Equal(t, "France", resp.Countries[0].Name)
+
+	require.Len(t, resp.Metrics, 2)
+	assert.Equal(t, 2, resp.Metrics[0].PopulationRank)
+	assert.Equal(t, 1, resp.Metrics[0].AreaRank)
+	assert.Equal(t, 123.257, *resp.Metrics[0].Density)
+	assert.Equal(t, 1, resp.Metrics[1].DensityRank)
+
+	require.Len(t, resp.Pairs, 1)
+	pair := resp.Pairs[0]
+	assert.Equal(t, "FRA", pair.A)
+	assert.Equal(t, "DEU", pair.B)
+	assert.Equal(t, 0.819, *pair.PopulationRatio)
+	assert.Equal(t, 1.545, *pair.AreaRatio)
+	assert.True(t, pair.Neighbours)
+	assert.Equal(t, []string{"EUR"}, pair.SharedCurrencies)
+	assert.Empty(t, pair.SharedLanguages)
+	assert.Equal(t, []string{"BEL", "LUX", "CHE"}, pair.SharedBorders)
+}
+
+func TestCompareHandler_SharedByAll(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	_, resp := fetch[compareResponse](t, s, "GET", "/api/countries/compare?names=France,Germany,CHE", "")
+
+	assert.Len(t, resp.Pairs, 3)
+	assert.Empty(t, resp.Shared.Currencies)
+	assert.Empty(t, resp.Shared.Languages)
+	assert.Empty(t, resp.Shared.Borders, "each borders the others, but no fourth country borders all three")
+
+	_, resp = fetch[compareResponse](t, s, "GET", "/api/countries/compare?names=France,Switzerland", "")
+	assert.Equal(t, []string{"French"}, resp.Shared.Languages)
+	assert.Equal(t, []string{"DEU", "ITA"}, resp.Shared.Borders, "in the first country's order")
+}
+
+func TestCompareHandler_MissingAreaLeavesDensityOut(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr, resp := fetch[compareResponse](t, s, "GET", "/api/countries/compare?names=Vatican%20City,France", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, resp.Metrics[0].Density)
+	assert.Zero(t, resp.Metrics[0].DensityRank)
+	assert.Equal(t, 1, resp.Metrics[1].DensityRank)
+	assert.Equal(t, 0.0, *resp.Pairs[0].AreaRatio)
+	assert.Nil(t, resp.Pairs[0].DensityRatio)
+	assert.Equal(t, []string{"EUR"}, resp.Shared.Currencies)
+}
+
+func TestCompareHandler_EqualValuesShareARank(t *testing.T) {
+	metrics := compareCountries([]externalapi.Country{
+		{Name: "A", Population: 10},
+		{Name: "B", Population: 20},
+		{Name: "C", Population: 10},
+	}).Metrics
+
+	assert.Equal(t, []int{2, 1, 2}, []int{metrics[0].PopulationRank, metrics[1].PopulationRank, metrics[2].PopulationRank})
+}
+
+func TestCompareHandler_BadRequests(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	for _, names := range []string{"", "France", "France,,%20", "a,b,c,d,e,f,g,h,i,j,k"} {
+		rr := serve(t, s, "GET", "/api/countries/compare?names="+names, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, names)
+	}
+}
+
+func TestCompareHandler_NamesMissingCountries(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "GET", "/api/countries/compare?names=France,Atlantis,Lemuria", "")
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "Country not found: Atlantis, Lemuria\n", rr.Body.String())
+}
+
+func TestCompareHandler_Unavailable(t *testing.T) {
+	s := setupTestServer()
+	s.cache.Set("france", externalapi.Country{Name: "France"})
+	s.provider = &stubProvider{err: fmt.Errorf("stub: %w", externalapi.ErrUnavailable)}
+
+	rr := serve(t, s, "GET", "/api/countries/compare?names=France,Germany", "")
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestCompareHandler_UpstreamFailing(t *testing.T) {
+	s := setupTestServer()
+	s.cache.Set("france", externalapi.Country{Name: "France"})
+	s.provider = &stubProvider{err: fmt.Errorf("stub: %w", externalapi.ErrUpstreamFailed)}
+
+	rr := serve(t, s, "GET", "/api/countries/compare?names=France,Germany", "")
+
+	assert.Equal(t, http.StatusBadGateway, rr.Code)
+	assert.Equal(t, "Country service returned a bad response\n", rr.Body.String())
+}
+
+func TestCompareHandler_UsesCachedLookups(t *testing.T) {
+	s := setupTestServer()
+	provider := &slowProvider{}
+	s.provider = provider
+
+	serve(t, s, "GET", "/api/countries/compare?names=Chile,Peru", "")
+	rr := serve(t, s, "GET", "/api/countries/compare?names=Peru,Chile", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, int32(2), provider.calls.Load())
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// enrichStatusColumn is appended after the requested fields. It is empty
+// for rows that were enriched and says why for the rest.
+const enrichStatusColumn = "enrich_error"
+
+const (
+	// enrichFlushRows is how many rows are written between flushes.
+	enrichFlushRows = 100
+	// maxEnrichMemo bounds the distinct names remembered per upload.
+	maxEnrichMemo = 10000
+	// defaultEnrichMaxBytes caps an upload when no limit is configured.
+	defaultEnrichMaxBytes = 32 << 20
+)
+
+var defaultEnrichFields = []string{"capital", "currency", "population", "alpha2_code", "alpha3_code"}
+
+// enrichFields are the columns the enrich endpoint can append.
+var enrichFields = map[string]func(externalapi.Country) string{
+	"name":         func(c externalapi.Country) string { return c.Name },
+	"capital":      func(c externalapi.Country) string { return c.Capital },
+	"region":       func(c externalapi.Country) string { return c.Region },
+	"subregion":    func(c externalapi.Country) string { return c.Subregion },
+	"population":   func(c externalapi.Country) string { return strconv.Itoa(c.Population) },
+	"alpha2_code":  func(c externalapi.Country) string { return c.Alpha2Code },
+	"alpha3_code":  func(c externalapi.Country) string { return c.Alpha3Code },
+	"numeric_code": func(c externalapi.Country) string { return c.NumericCode },
+	"currency": func(c externalapi.Country) string {
+		codes := make([]string, 0, len(c.Currencies))
+		for _, cur := range c.Currencies {
+			codes = append(codes, cmp.Or(cur.Code, cur.Symbol))
+		}
+		return strings.Join(codes, ";")
+	},
+}
+
+func parseEnrichFields(param string) ([]string, error) {
+	if strings.TrimSpace(param) == "" {
+		return defaultEnrichFields, nil
+	}
+
+	var fields []string
+	for _, f := range strings.Split(param, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if _, ok := enrichFields[f]; !ok {
+			return nil, fmt.Errorf("unknown field %q", f)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// EnrichHandler streams a CSV back with country fields appended to each
+// row, resolved from the named column's names or codes against the
+// country index. The CSV is the request body, or the "file" part of a
+// multipart upload, of at most enrichMaxBytes. Rows that cannot be
+// resolved keep empty fields and say why in the enrich_error column; they
+// never fail the file. An upload that can't be read to the end gets a
+// final row, empty but for enrich_error, saying where it was cut off.
+func (s *Server) EnrichHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	column := strings.TrimSpace(q.Get("column"))
+	if column == "" {
+		http.Error(w, "column is required", http.StatusBadRequest)
+		return
+	}
+	fields, err := parseEnrichFields(q.Get("fields"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(positiveOr(s.enrichMaxBytes, defaultEnrichMaxBytes)))
+	body, err := csvUpload(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	in := csv.NewReader(body)
+	in.FieldsPerRecord = -1
+	in.LazyQuotes = true
+
+	header, err := in.Read()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("Upload may be at most %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "CSV has no header row", http.StatusBadRequest)
+		return
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	col := slices.IndexFunc(header, func(h string) bool {
+		return strings.EqualFold(strings.TrimSpace(h), column)
+	})
+	if col < 0 {
+		http.Error(w, fmt.Sprintf("CSV has no %q column", column), http.StatusBadRequest)
+		return
+	}
+
+	// Rows go out while the upload is still coming in, which HTTP/1.1
+	// servers only allow when asked.
+	rc := http.NewResponseController(w)
+	_ = rc.EnableFullDuplex()
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="enriched.csv"`)
+	out := csv.NewWriter(w)
+
+	if err := out.Write(append(header, append(fields, enrichStatusColumn)...)); err != nil {
+		return
+	}
+
+	resolve := s.countryResolver(r.Context())
+
+	// Unresolved names are remembered too; the cache only keeps countries.
+	type resolved struct {
+		country externalapi.Country
+		problem string
+	}
+	seen := make(map[string]resolved)
+	for rows := 1; ; rows++ {
+		record, err := in.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// The 200 has gone out, so the client learns the file was
+			// cut short from a last row rather than the status.
+			log.Printf("error reading CSV to enrich after %d rows: %v", rows-1, err)
+			_ = out.Write(append(make([]string, len(header)+len(fields)), truncatedUpload(err, rows-1)))
+			break
+		}
+
+		// Short rows are padded to the header, so the appended fields
+		// line up under their own columns. Long rows are cut to it and
+		// flagged rather than enriched.
+		wide := len(record) > len(header)
+		if wide {
+			record = record[:len(header)]
+		}
+		for len(record) < len(header) {
+			record = append(record, "")
+		}
+
+		name := strings.TrimSpace(record[col])
+		res, ok := seen[cacheKey(name)]
+		switch {
+		case wide:
+			res = resolved{problem: fmt.Sprintf("Row has more than the header's %d cells; the rest were dropped", len(header))}
+		case ok:
+		default:
+			if name == "" {
+				res.problem = "Country name is empty"
+			} else if country, err := resolve(r.Context(), name); err != nil {
+				_, res.problem = lookupStatus(err)
+			} else {
+				res.country = country
+			}
+			if len(seen) < maxEnrichMemo {
+				seen[cacheKey(name)] = res
+			}
+		}
+
+		for _, f := range fields {
+			value := ""
+			if res.problem == "" {
+				value = enrichFields[f](res.country)
+			}
+			record = append(record, value)
+		}
+		if err := out.Write(append(record, res.problem)); err != nil {
+			return
+		}
+		if rows%enrichFlushRows == 0 {
+			out.Flush()
+			_ = rc.Flush()
+		}
+	}
+	out.Flush()
+}
+
+// truncatedUpload says why an upload stopped being read after rows rows.
+func truncatedUpload(err error, rows int) string {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return fmt.Sprintf("Upload cut off after %d rows: it is larger than %d bytes", rows, tooLarge.Limit)
+	}
+	return fmt.Sprintf("Upload cut off after %d rows: %v", rows, err)
+}
+
+// csvUpload returns the uploaded CSV without buffering it.
+func csvUpload(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return nil, fmt.Errorf("upload has no file part")
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
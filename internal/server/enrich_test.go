@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrichHandler_AppendsDefaultFields(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "POST", "/api/countries/enrich?column=country", "id,country\n1,france\n2,Panama\n")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "id,country,capital,currency,population,alpha2_code,alpha3_code,enrich_error\n"+
+		"1,france,Paris,EUR,68000000,FR,FRA,\n"+
+		"2,Panama,Panama City,PAB;USD,4300000,PA,PAN,\n", rr.Body.String())
+}
+
+func TestEnrichHandler_FlagsUnresolvedRows(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "POST", "/api/countries/enrich?column=Country&fields=capital", "Country,note\nAtlantis,lost\n,blank\nFrance,\"a, b\"\nshort\n")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	reader := csv.NewReader(rr.Body)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Country", "note", "capital", "enrich_error"},
+		{"Atlantis", "lost", "", "Country not found"},
+		{"", "blank", "", "Country name is empty"},
+		{"France", "a, b", "Paris", ""},
+		{"short", "", "", "Country not found"},
+	}, records)
+}
+
+func TestEnrichHandler_AlignsRaggedRows(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "POST", "/api/countries/enrich?column=country&fields=capital", "id,country,notes\n1,France\n2,Chile,a,b,c\n3,Panama,ok\n")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	require.NoError(t, err, "every row is as wide as the header")
+	assert.Equal(t, [][]string{
+		{"id", "country", "notes", "capital", "enrich_error"},
+		{"1", "France", "", "Paris", ""},
+		{"2", "Chile", "a", "", "Row has more than the header's 3 cells; the rest were dropped"},
+		{"3", "Panama", "ok", "Panama City", ""},
+	}, records)
+}
+
+func TestEnrichHandler_ColumnMatchIgnoresCaseAndBOM(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "POST", "/api/countries/enrich?column=COUNTRY&fields=alpha3_code,numeric_code", "\ufeffCountry\nFrance\n")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "Country,alpha3_code,numeric_code,enrich_error\nFrance,FRA,250,\n", rr.Body.String())
+}
+
+func TestEnrichHandler_ResolvesAgainstFullListUntilSynced(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: testCountries}
+	s.provider = stub
+
+	rr := serve(t, s, "POST", "/api/countries/enrich?column=country&fields=name", "country\nfrance\nDE\nChile\n")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "country,name,enrich_error\nfrance,France,\nDE,Germany,\nChile,Chile,\n", rr.Body.String())
+	assert.Equal(t, 1, stub.calls, "one list, not a search per row")
+}
+
+func TestEnrichHandler_MarksCutOffUploads(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+	s.enrichMaxBytes = 40
+
+	rr := serve(t, s, "POST", "/api/countries/enrich?column=country&fields=capital", "country\n"+strings.Repeat("France\n", 10))
+
+	require.Equal(t, http.StatusOK, rr.Code, "rows were already sent")
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 6)
+	assert.Equal(t, []string{"France", "Paris", ""}, records[4])
+	assert.Equal(t, []string{"", "", "Upload cut off after 4 rows: it is larger than 40 bytes"}, records[5])
+
+	s.enrichMaxBytes = 4
+	rr = serve(t, s, "POST", "/api/countries/enrich?column=country", "country\nFrance\n")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
+
+func TestEnrichHandler_BadRequests(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	for query, body := range map[string]string{
+		"":                                 "country\nFrance\n",
+		"?column=nation":                   "country\nFrance\n",
+		"?column=country&fields=flag":      "country\nFrance\n",
+		"?column=country&fields=capital,,": "country\nFrance\n",
+		"?column=country":                  "",
+	} {
+		rr := serve(t, s, "POST", "/api/countries/enrich"+query, body)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestEnrichHandler_MultipartUpload(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("comment", "quarterly"))
+	fw, err := mw.CreateFormFile("file", "countries.csv")
+	require.NoError(t, err)
+	_, _ = io.WriteString(fw, "country\nPanama\n")
+	require.NoError(t, mw.Close())
+
+	rr := serve(t, s, "POST", "/api/countries/enrich?column=country&fields=capital", body.String(), "Content-Type", mw.FormDataContentType())
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "country,capital,enrich_error\nPanama,Panama City,\n", rr.Body.String())
+}
+
+func TestEnrichHandler_LooksUpEachNameOnce(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{}
+	s.provider = stub
+
+	serve(t, s, "POST", "/api/countries/enrich?column=country", "country\nAtlantis\natlantis\nAtlantis\n")
+
+	assert.Equal(t, 1, stub.calls)
+}
+
+func TestEnrichHandler_ListsOnceWhenTheIndexFails(t *testing.T) {
+	s := setupTestServer()
+	provider := &slowProvider{}
+	s.provider = provider
+
+	rr := serve(t, s, "POST", "/api/countries/enrich?column=country", "country\nFR\nDE\n036\nFrance\n")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, int32(1), provider.listCalls.Load())
+	assert.Equal(t, int32(4), provider.calls.Load())
+}
+
+func TestEnrichHandler_StreamsLargeUploads(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+	srv := httptest.NewServer(s.RegisterRoutes())
+	t.Cleanup(srv.Close)
+
+	// The response starts before the upload ends, so a server that
+	// stopped reading once it had written would cut the upload short.
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = io.WriteString(pw, "country\n")
+		for i := range 5000 {
+			_, _ = fmt.Fprintf(pw, "%s\n", testCountries[i%2].Name)
+		}
+		_ = pw.Close()
+	}()
+	resp, err := http.Post(srv.URL+"/api/countries/enrich?column=country&fields=alpha2_code", "text/csv", pr)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, 5001, len(records))
+	assert.Equal(t, []string{"Germany", "DE", ""}, records[5000])
+}
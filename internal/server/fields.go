@@ -0,0 +1,85 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/fieldset"
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// The record types the fields query parameter is checked against.
+var (
+	searchResponseType = reflect.TypeFor[externalapi.CountrySearchResponse]()
+	countryType        = reflect.TypeFor[externalapi.Country]()
+	completionType     = reflect.TypeFor[completion]()
+	neighbourType      = reflect.TypeFor[neighbour]()
+	countryRefType     = reflect.TypeFor[countryRef]()
+)
+
+// parseFields reads the fields query parameter for records of type
+// record, answering 400 and returning false when it names an unknown
+// field. Without the parameter the set is nil and keeps every field.
+func parseFields(w http.ResponseWriter, r *http.Request, record reflect.Type) (fieldset.Set, bool) {
+	fields, err := fieldset.Parse(r.URL.Query().Get("fields"), record)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return fields, true
+}
+
+// writeFields writes v with the records at the path of JSON field names
+// at trimmed to fields.
+func writeFields(w http.ResponseWriter, v any, fields fieldset.Set, at ...string) {
+	raw, err := fields.Project(v, at...)
+	if err != nil {
+		log.Fatalf("error handling JSON marshal. Err: %v", err)
+	}
+	_, _ = w.Write(raw)
+}
+
+// projectionKey is the cache key for the record cached under key trimmed
+// to fields, kept apart from the full record.
+func projectionKey(key string, fields fieldset.Set) string {
+	return "fields:" + fields.String() + ":" + key
+}
+
+// writeCachedProjection writes the record trimmed to fields if a fresh
+// projection is cached, and reports whether it did.
+func (s *Server) writeCachedProjection(w http.ResponseWriter, key string, fields fieldset.Set) bool {
+	if fields == nil {
+		return false
+	}
+	value, ok := s.cache.Get(projectionKey(key, fields))
+	if !ok {
+		return false
+	}
+	entry, ok := value.(cacheEntry)
+	if !ok || !entry.fresh(time.Now()) {
+		return false
+	}
+	raw, ok := entry.value.(json.RawMessage)
+	if !ok {
+		return false
+	}
+	_, _ = w.Write(raw)
+	return true
+}
+
+// writeProjection writes record trimmed to fields. The result is cached
+// until from, the fresh cache entry the record was looked up from,
+// expires; records from the synced index or from stale or offline data
+// have no entry, and their projections aren't cached.
+func (s *Server) writeProjection(w http.ResponseWriter, key string, record any, fields fieldset.Set, from *cacheEntry) {
+	raw, err := fields.Project(record)
+	if err != nil {
+		log.Fatalf("error handling JSON marshal. Err: %v", err)
+	}
+	if fields != nil && from != nil {
+		s.cache.Set(projectionKey(key, fields), cacheEntry{value: raw, expires: from.expires})
+	}
+	_, _ = w.Write(raw)
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFields_SearchV2(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{countries: testCountries}
+
+	rr := serve(t, s, "GET", "/api/v2/countries/search?name=panama&fields=name,capital,currencies.code,flags.png", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"name":"Panama","capital":"Panama City","currencies":[{"code":"PAB"},{"code":"USD"}],"flags":{"png":"https://flags.example/pa.png"}}`, rr.Body.String())
+}
+
+func TestFields_SearchV1(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{countries: testCountries}
+
+	rr := serve(t, s, "GET", "/api/countries/search?name=panama&fields=currency,name", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"name":"Panama","currency":"B/."}`, rr.Body.String())
+
+	rr = serve(t, s, "GET", "/api/countries/search?name=panama&fields=currencies.code", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "v1 records have no currencies")
+}
+
+func TestFields_UnknownFieldsAreRejected(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: testCountries}
+	s.provider = stub
+
+	for target, want := range map[string]string{
+		"/api/v2/countries/search?name=panama&fields=name,motto": `unknown field "motto" in "motto"`,
+		"/api/countries/code/PA?fields=currencies.rate":          `unknown field "rate" in "currencies.rate"`,
+		"/api/countries?fields=name.common":                      `field "common" has no fields in "name.common"`,
+		"/api/countries/compare?names=PA,CL&fields=Name":         `unknown field "Name" in "Name"`,
+		"/api/countries/autocomplete?q=pa&fields=capital":        `unknown field "capital" in "capital"`,
+		"/api/countries/PA/neighbours?fields=region":             `unknown field "region" in "region"`,
+		"/api/routes?from=PA&to=CL&fields=distance":              `unknown field "distance" in "distance"`,
+	} {
+		rr := serve(t, s, "GET", target, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+		assert.Equal(t, want+"\n", rr.Body.String(), target)
+	}
+	assert.Zero(t, stub.calls, "fields are checked before any lookup")
+}
+
+func TestFields_CurrencyCodeIsCurrenciesCode(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "GET", "/api/v2/countries/search?name=panama&fields=currencies.code", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"currencies":[{"code":"PAB"},{"code":"USD"}]}`, rr.Body.String())
+
+	for target, want := range map[string]string{
+		"/api/v2/countries/search?name=panama&fields=currency.code": `unknown field "currency" in "currency.code"`,
+		"/api/countries/search?name=panama&fields=currency.code":    `field "code" has no fields in "currency.code"`,
+	} {
+		rr := serve(t, s, "GET", target, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+		assert.Equal(t, want+"\n", rr.Body.String(), target)
+	}
+}
+
+func TestFields_ProjectionCachedApart(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: testCountries}
+	s.provider = stub
+
+	for range 2 {
+		rr := serve(t, s, "GET", "/api/v2/countries/search?name=Panama&fields=name,capital", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `{"name":"Panama","capital":"Panama City"}`, rr.Body.String())
+	}
+	assert.Equal(t, 1, stub.calls)
+
+	projected, ok := s.cache.Get("fields:capital,name:v2:panama")
+	require.True(t, ok)
+	assert.JSONEq(t, `{"name":"Panama","capital":"Panama City"}`, string(projected.(cacheEntry).value.(json.RawMessage)))
+	full, ok := s.cache.Get("panama")
+	require.True(t, ok)
+	assert.Equal(t, "Panama", full.(cacheEntry).value.(externalapi.Country).Name)
+
+	rr := serve(t, s, "GET", "/api/v2/countries/search?name=Panama&fields=capital,name", "")
+	assert.Equal(t, `{"name":"Panama","capital":"Panama City"}`, rr.Body.String(), "field order doesn't matter to the cache")
+	assert.Equal(t, 1, stub.calls)
+}
+
+func TestFields_NotCachedFromSyncedIndex(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "GET", "/api/countries/code/CL?fields=name", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"name":"Chile"}`, rr.Body.String())
+	_, ok := s.cache.Get("fields:name:code:CL")
+	assert.False(t, ok)
+}
+
+func TestFields_NotCachedFromOfflineData(t *testing.T) {
+	s := setupTestServer()
+	upstream := &stubProvider{err: errors.New("connection refused")}
+	s.provider = upstream
+	s.offline = &stubProvider{countries: []externalapi.Country{{Name: "France", Capital: "OFFLINE"}}}
+
+	rr := serve(t, s, "GET", "/api/v2/countries/search?name=france&fields=capital", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"capital":"OFFLINE"}`, rr.Body.String())
+	_, ok := s.cache.Get("fields:capital:v2:france")
+	assert.False(t, ok)
+
+	upstream.err = nil
+	upstream.countries = []externalapi.Country{{Name: "France", Capital: "Paris"}}
+	rr = serve(t, s, "GET", "/api/v2/countries/search?name=france&fields=capital", "")
+	assert.Equal(t, `{"capital":"Paris"}`, rr.Body.String(), "the upstream is asked again once it recovers")
+}
+
+func TestFields_ProjectionExpiresWithRecord(t *testing.T) {
+	s := setupTestServer()
+	s.cacheTTL = time.Hour
+	s.provider = &stubProvider{countries: testCountries}
+	expires := time.Now().Add(time.Minute)
+	s.cache.Set("panama", cacheEntry{value: externalapi.Country{Name: "Panama"}, expires: expires})
+
+	rr := serve(t, s, "GET", "/api/v2/countries/search?name=panama&fields=name", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	projected, ok := s.cache.Get("fields:name:v2:panama")
+	require.True(t, ok)
+	assert.Equal(t, expires, projected.(cacheEntry).expires, "not the hour-long cache TTL")
+}
+
+func TestFields_EveryCountryEndpoint(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "GET", "/api/countries?fields=alpha2_code&region=Americas&sort=name", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"countries":[{"alpha2_code":"CA"},{"alpha2_code":"CL"},{"alpha2_code":"PA"}],"total":3}`, rr.Body.String())
+
+	rr = serve(t, s, "POST", "/api/countries/batch?fields=capital", `["PA", "Atlantis"]`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"results":[{"query":"PA","country":{"capital":"Panama City"},"status":200},{"query":"Atlantis","status":404,"error":"Country not found"}]}`, rr.Body.String())
+
+	rr = serve(t, s, "GET", "/api/countries/compare?names=PA,CL&fields=name", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var compared struct {
+		Countries []map[string]any `json:"countries"`
+		Metrics   []any            `json:"metrics"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &compared))
+	assert.Equal(t, []map[string]any{{"name": "Panama"}, {"name": "Chile"}}, compared.Countries)
+	assert.Len(t, compared.Metrics, 2, "only the country records are trimmed")
+
+	rr = serve(t, s, "GET", "/api/countries/autocomplete?q=ger&fields=name", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"query":"ger","suggestions":[{"name":"Germany"}]}`, rr.Body.String())
+
+	rr = serve(t, s, "GET", "/api/countries/PT/neighbours?fields=alpha3_code", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"country":{"alpha3_code":"PRT","name":"Portugal"},"depth":1,"neighbours":[{"alpha3_code":"ESP"}]}`, rr.Body.String())
+
+	rr = serve(t, s, "GET", "/api/routes?from=PT&to=FR&fields=name", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"from":{"alpha3_code":"PRT","name":"Portugal"},"to":{"alpha3_code":"FRA","name":"France"},"reachable":true,"crossings":2,"path":[{"name":"Portugal"},{"name":"Spain"},{"name":"France"}]}`, rr.Body.String())
+
+	rr = serve(t, s, "GET", "/api/v2/countries/search?name=pana&mode=fuzzy&fields=population", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var fuzzy struct {
+		Match map[string]any `json:"match"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &fuzzy))
+	assert.Equal(t, map[string]any{"population": 4300000.0}, fuzzy.Match)
+}
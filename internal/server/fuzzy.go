@@ -0,0 +1,97 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/fieldset"
+	"CountrySearch/internal/match"
+	"net/http"
+)
+
+const (
+	// ambiguityMargin is how far the best candidate must score ahead of the
+	// runner-up to be picked without asking the client to choose.
+	ambiguityMargin = 0.05
+	maxAlternatives = 5
+)
+
+type candidate struct {
+	Name       string     `json:"name"`
+	Alpha3Code string     `json:"alpha3_code,omitempty"`
+	Score      float64    `json:"score"`
+	Match      match.Kind `json:"match"`
+}
+
+// fuzzyResponse is returned when one candidate is a clear winner.
+type fuzzyResponse struct {
+	Match        any         `json:"match"`
+	Score        float64     `json:"score"`
+	MatchKind    match.Kind  `json:"match_kind"`
+	Alternatives []candidate `json:"alternatives"`
+}
+
+// disambiguationResponse is returned with 300 Multiple Choices when the
+// top candidates are too close to call.
+type disambiguationResponse struct {
+	Query      string      `json:"query"`
+	Candidates []candidate `json:"candidates"`
+}
+
+func isFuzzy(r *http.Request) bool {
+	return r.URL.Query().Get("mode") == "fuzzy"
+}
+
+// fuzzySearch ranks every upstream candidate for the name query parameter.
+// project shapes the winning country for the endpoint's response version,
+// and fields trims it.
+func (s *Server) fuzzySearch(w http.ResponseWriter, r *http.Request, fields fieldset.Set, project func(externalapi.Country) any) {
+	name := r.URL.Query().Get("name")
+	countries, err := s.searchCandidates(r.Context(), name)
+	if err != nil {
+		s.writeSearchError(w, r, name, err)
+		return
+	}
+
+	ranked := match.Rank(name, countries, externalapi.Country.Names)
+	if len(ranked) == 0 {
+		s.writeSearchError(w, r, name, externalapi.ErrCountryNotFound)
+		return
+	}
+
+	if ambiguous(ranked) {
+		resp := disambiguationResponse{Query: name}
+		for _, rc := range ranked[:min(len(ranked), maxAlternatives)] {
+			resp.Candidates = append(resp.Candidates, toCandidate(rc))
+		}
+		w.WriteHeader(http.StatusMultipleChoices)
+		writeJSON(w, resp)
+		return
+	}
+
+	best := ranked[0]
+	resp := fuzzyResponse{
+		Match:        project(best.Item),
+		Score:        best.Score,
+		MatchKind:    best.Kind,
+		Alternatives: []candidate{},
+	}
+	for _, rc := range ranked[1:min(len(ranked), maxAlternatives+1)] {
+		resp.Alternatives = append(resp.Alternatives, toCandidate(rc))
+	}
+	writeFields(w, resp, fields, "match")
+}
+
+func ambiguous(ranked []match.Ranked[externalapi.Country]) bool {
+	if len(ranked) < 2 || ranked[0].Kind == match.KindExact {
+		return false
+	}
+	return ranked[0].Score-ranked[1].Score < ambiguityMargin
+}
+
+func toCandidate(rc match.Ranked[externalapi.Country]) candidate {
+	return candidate{
+		Name:       rc.Item.Name,
+		Alpha3Code: rc.Item.Alpha3Code,
+		Score:      rc.Score,
+		Match:      rc.Kind,
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/match"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fuzzyTestServer() *Server {
+	s := setupTestServer()
+	s.provider = &stubProvider{countries: []externalapi.Country{
+		{Name: "Guinea", Alpha3Code: "GIN"},
+		{Name: "Guinea-Bissau", Alpha3Code: "GNB"},
+		{Name: "Equatorial Guinea", Alpha3Code: "GNQ"},
+		{Name: "Papua New Guinea", Alpha3Code: "PNG"},
+		{Name: "Korea (Democratic People's Republic of)", Alpha3Code: "PRK"},
+		{Name: "Korea (Republic of)", Alpha3Code: "KOR"},
+		{Name: "Dominica", Alpha3Code: "DMA"},
+	}}
+	return s
+}
+
+func TestFuzzySearch_ExactWithAlternatives(t *testing.T) {
+	s := fuzzyTestServer()
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v2/countries/search?name=guinea&mode=fuzzy", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Match        externalapi.Country `json:"match"`
+		MatchKind    match.Kind          `json:"match_kind"`
+		Alternatives []candidate         `json:"alternatives"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "GIN", resp.Match.Alpha3Code)
+	assert.Equal(t, match.KindExact, resp.MatchKind)
+	require.Len(t, resp.Alternatives, 3)
+	assert.Equal(t, "Guinea-Bissau", resp.Alternatives[0].Name)
+	assert.Equal(t, match.KindPrefix, resp.Alternatives[0].Match)
+}
+
+func TestFuzzySearch_AmbiguousIsMultipleChoices(t *testing.T) {
+	s := fuzzyTestServer()
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/search?name=Korea&mode=fuzzy", nil))
+
+	require.Equal(t, http.StatusMultipleChoices, rr.Code)
+	var resp disambiguationResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "Korea", resp.Query)
+	require.Len(t, resp.Candidates, 2)
+	assert.Equal(t, "KOR", resp.Candidates[0].Alpha3Code)
+	assert.Equal(t, "PRK", resp.Candidates[1].Alpha3Code)
+}
+
+func TestFuzzySearch_V1ProjectsMatch(t *testing.T) {
+	s := fuzzyTestServer()
+
+	rr := httptest.NewRecorder()
+	s.SearchCountryHandler(rr, httptest.NewRequest("GET", "/api/countries/search?name=dominica&mode=fuzzy", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"match": {"name": "Dominica", "capital": "", "currency": "", "population": 0},
+		"score": 1,
+		"match_kind": "exact",
+		"alternatives": []
+	}`, rr.Body.String())
+}
+
+func TestFuzzySearch_NotFound(t *testing.T) {
+	s := fuzzyTestServer()
+
+	rr := httptest.NewRecorder()
+	s.SearchCountryV2Handler(rr, httptest.NewRequest("GET", "/api/v2/countries/search?name=atlantis&mode=fuzzy", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestFuzzySearch_CachesCandidates(t *testing.T) {
+	s := fuzzyTestServer()
+	stub := s.provider.(*stubProvider)
+
+	for i := 0; i < 3; i++ {
+		rr := httptest.NewRecorder()
+		s.SearchCountryV2Handler(rr, httptest.NewRequest("GET", "/api/v2/countries/search?name=guinea&mode=fuzzy", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	assert.Equal(t, 1, stub.calls)
+}
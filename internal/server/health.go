@@ -0,0 +1,97 @@
+package server
+
+import (
+	"CountrySearch/internal/breaker"
+	"CountrySearch/internal/datasync"
+	"CountrySearch/internal/hedge"
+	"CountrySearch/internal/probe"
+	"CountrySearch/internal/ratelimit"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+type healthResponse struct {
+	Status   string           `json:"status"`
+	Upstream *upstreamHealth  `json:"upstream,omitempty"`
+	Sync     *datasync.Status `json:"sync,omitempty"`
+}
+
+type upstreamHealth struct {
+	Name      string              `json:"name"`
+	Breaker   *breaker.Snapshot   `json:"breaker,omitempty"`
+	RateLimit *ratelimit.Snapshot `json:"rate_limit,omitempty"`
+	Hedge     *hedge.Snapshot     `json:"hedge,omitempty"`
+}
+
+func (s *Server) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	resp := healthResponse{Status: "ok"}
+
+	if s.provider != nil {
+		resp.Upstream = &upstreamHealth{Name: s.provider.Name()}
+		if s.breaker != nil {
+			snap := s.breaker.Snapshot()
+			resp.Upstream.Breaker = &snap
+			if snap.State != breaker.StateClosed.String() {
+				resp.Status = "degraded"
+			}
+		}
+		if s.limiter != nil {
+			snap := s.limiter.Snapshot()
+			resp.Upstream.RateLimit = &snap
+		}
+		if s.hedge != nil {
+			snap := s.hedge.Snapshot()
+			resp.Upstream.Hedge = &snap
+		}
+	}
+
+	if s.upstreamDown() {
+		resp.Status = "degraded"
+	}
+
+	if s.syncer != nil {
+		status := s.syncer.Status()
+		resp.Sync = &status
+	}
+
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		log.Fatalf("error handling JSON marshal. Err: %v", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(jsonResp)
+}
+
+type upstreamsHealthResponse struct {
+	Status    string         `json:"status"`
+	Upstreams []probe.Status `json:"upstreams"`
+}
+
+// UpstreamsHealthHandler reports the background probes of each upstream.
+// It answers 503 while every upstream is failing them.
+func (s *Server) UpstreamsHealthHandler(w http.ResponseWriter, r *http.Request) {
+	resp := upstreamsHealthResponse{Status: "ok", Upstreams: []probe.Status{}}
+	if s.prober != nil {
+		resp.Upstreams = s.prober.Status()
+	}
+
+	status := http.StatusOK
+	for _, u := range resp.Upstreams {
+		if !u.Healthy {
+			resp.Status = "degraded"
+		}
+	}
+	if s.upstreamDown() {
+		resp.Status = "down"
+		status = http.StatusServiceUnavailable
+	}
+
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		log.Fatalf("error handling JSON marshal. Err: %v", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(jsonResp)
+}
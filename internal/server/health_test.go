@@ -0,0 +1,163 @@
+package server
+
+import (
+	"CountrySearch/internal/breaker"
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/hedge"
+	"CountrySearch/internal/probe"
+	"CountrySearch/internal/ratelimit"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHandler_ReportsClosedBreaker(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{}
+	s.breaker = breaker.New(breaker.DefaultConfig())
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/health", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var resp healthResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "ok", resp.Status)
+	require.NotNil(t, resp.Upstream)
+	assert.Equal(t, "stub", resp.Upstream.Name)
+	assert.Equal(t, "closed", resp.Upstream.Breaker.State)
+}
+
+func TestHealthHandler_DegradedWhenBreakerOpen(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{}
+	s.breaker = breaker.New(breaker.Config{MinRequests: 1, FailureRate: 1, CoolDown: time.Minute})
+	require.NoError(t, s.breaker.Allow())
+	s.breaker.Failure()
+
+	rr := httptest.NewRecorder()
+	s.HealthHandler(rr, httptest.NewRequest("GET", "/health", nil))
+
+	var resp healthResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, "open", resp.Upstream.Breaker.State)
+	assert.NotNil(t, resp.Upstream.Breaker.OpenUntil)
+}
+
+func TestHealthHandler_WithoutProvider(t *testing.T) {
+	s := setupTestServer()
+
+	rr := httptest.NewRecorder()
+	s.HealthHandler(rr, httptest.NewRequest("GET", "/health", nil))
+
+	assert.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+func TestHealthHandler_ReportsRateLimit(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{}
+	s.limiter = ratelimit.New(ratelimit.Config{Rate: 1, Burst: 3, DailyQuota: 50, Mode: ratelimit.ModeStale})
+
+	rr := httptest.NewRecorder()
+	s.HealthHandler(rr, httptest.NewRequest("GET", "/health", nil))
+
+	var resp healthResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Upstream.RateLimit)
+	assert.Equal(t, ratelimit.ModeStale, resp.Upstream.RateLimit.Mode)
+	assert.Equal(t, 50, resp.Upstream.RateLimit.QuotaLimit)
+}
+
+func TestHealthHandler_ReportsHedge(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{}
+	s.hedge = hedge.New(hedge.Config{MinDelay: 10 * time.Millisecond, MaxDelay: 300 * time.Millisecond})
+
+	rr := httptest.NewRecorder()
+	s.HealthHandler(rr, httptest.NewRequest("GET", "/health", nil))
+
+	var resp healthResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Upstream.Hedge)
+	assert.Equal(t, "300ms", resp.Upstream.Hedge.Delay, "no samples yet")
+}
+
+func TestUpstreamsHealthHandler_WithoutProber(t *testing.T) {
+	s := setupTestServer()
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/health/upstreams", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ok","upstreams":[]}`, rr.Body.String())
+}
+
+func TestUpstreamsHealthHandler_ReportsProbes(t *testing.T) {
+	s := setupTestServer()
+	up := &stubProvider{countries: []externalapi.Country{{Name: "France"}}}
+	down := &stubProvider{name: "backup", err: errors.New("connection refused")}
+	s.prober = probe.New(probe.Config{FailureThreshold: 1}, up, down)
+	s.prober.ProbeAll(context.Background())
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/health/upstreams", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp upstreamsHealthResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "degraded", resp.Status)
+	require.Len(t, resp.Upstreams, 2)
+	assert.True(t, resp.Upstreams[0].Healthy)
+	assert.False(t, resp.Upstreams[1].Healthy)
+	assert.Equal(t, "connection refused", resp.Upstreams[1].LastError)
+	assert.Len(t, resp.Upstreams[1].History, 1)
+}
+
+func TestUpstreamsHealthHandler_DownWhenEveryProbeFails(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{err: errors.New("connection refused")}
+	s.prober = probe.New(probe.Config{FailureThreshold: 1}, s.provider)
+	s.prober.ProbeAll(context.Background())
+
+	rr := httptest.NewRecorder()
+	s.UpstreamsHealthHandler(rr, httptest.NewRequest("GET", "/health/upstreams", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"status":"down"`)
+
+	rr = httptest.NewRecorder()
+	s.HealthHandler(rr, httptest.NewRequest("GET", "/health", nil))
+	assert.Contains(t, rr.Body.String(), `"status":"degraded"`)
+}
+
+func TestHealthHandler_ReportsSync(t *testing.T) {
+	s, _ := syncedServer(t, externalapi.Country{Name: "France", Alpha3Code: "FRA"})
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/health", nil))
+
+	var resp healthResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Sync)
+	assert.True(t, resp.Sync.Loaded)
+	assert.Equal(t, 1, resp.Sync.Countries)
+}
+
+func TestDebugVars_PublishesSchemaStats(t *testing.T) {
+	s := setupTestServer()
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/debug/vars", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"upstream_schema"`)
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"encoding/json"
+	"mime"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	euro    = externalapi.Currency{Code: "EUR", Name: "Euro", Symbol: "€"}
+	english = externalapi.Language{ISO639_1: "en", ISO639_2: "eng", Name: "English"}
+	french  = externalapi.Language{ISO639_1: "fr", ISO639_2: "fra", Name: "French"}
+	german  = externalapi.Language{ISO639_1: "de", ISO639_2: "deu", Name: "German"}
+	italian = externalapi.Language{ISO639_1: "it", ISO639_2: "ita", Name: "Italian"}
+	spanish = externalapi.Language{ISO639_1: "es", ISO639_2: "spa", Name: "Spanish"}
+)
+
+// testCountries is the dataset the handler tests share. Borders are real
+// but point at countries left out of it too, which the border graph drops.
+var testCountries = []externalapi.Country{
+	{Name: "France", Capital: "Paris", Alpha2Code: "FR", Alpha3Code: "FRA", NumericCode: "250",
+		Region: "Europe", Subregion: "Western Europe", Population: 68000000, Area: 551695,
+		Borders:    []string{"AND", "BEL", "DEU", "ITA", "LUX", "MCO", "ESP", "CHE"},
+		Currencies: []externalapi.Currency{euro}, Languages: []externalapi.Language{french}},
+	{Name: "Germany", NativeName: "Deutschland", Capital: "Berlin", Alpha2Code: "DE", Alpha3Code: "DEU", NumericCode: "276",
+		Region: "Europe", Subregion: "Western Europe", Population: 83000000, Area: 357114,
+		Borders:    []string{"AUT", "BEL", "CZE", "DNK", "FRA", "LUX", "NLD", "POL", "CHE"},
+		Currencies: []externalapi.Currency{euro}, Languages: []externalapi.Language{german}},
+	{Name: "Belgium", Capital: "Brussels", Alpha2Code: "BE", Alpha3Code: "BEL", NumericCode: "056",
+		Region: "Europe", Subregion: "Western Europe", Population: 11500000, Area: 30528,
+		Borders:    []string{"FRA", "DEU", "LUX", "NLD"},
+		Currencies: []externalapi.Currency{euro}, Languages: []externalapi.Language{french, german}},
+	{Name: "Switzerland", Capital: "Bern", Alpha2Code: "CH", Alpha3Code: "CHE", NumericCode: "756",
+		Region: "Europe", Subregion: "Western Europe", Population: 8700000, Area: 41284,
+		Borders:    []string{"AUT", "FRA", "ITA", "LIE", "DEU"},
+		Currencies: []externalapi.Currency{{Code: "CHF", Name: "Swiss franc", Symbol: "Fr."}},
+		Languages:  []externalapi.Language{french, german, italian}},
+	{Name: "Åland Islands", Capital: "Mariehamn", Alpha2Code: "AX", Alpha3Code: "ALA", NumericCode: "248",
+		Region: "Europe", Subregion: "Northern Europe", Population: 28875, Area: 1580,
+		Currencies: []externalapi.Currency{euro}, Languages: []externalapi.Language{{ISO639_1: "sv", ISO639_2: "swe", Name: "Swedish"}}},
+	{Name: "Iceland", Capital: "Reykjavík", Alpha2Code: "IS", Alpha3Code: "ISL", NumericCode: "352",
+		Region: "Europe", Subregion: "Northern Europe", Population: 372000, Area: 103000,
+		Currencies: []externalapi.Currency{{Code: "ISK", Name: "Icelandic króna", Symbol: "kr"}},
+		Languages:  []externalapi.Language{{ISO639_1: "is", ISO639_2: "isl", Name: "Icelandic"}}},
+	{Name: "Portugal", Capital: "Lisbon", Alpha2Code: "PT", Alpha3Code: "PRT", NumericCode: "620",
+		Region: "Europe", Subregion: "Southern Europe", Population: 10300000, Area: 92090,
+		Borders:    []string{"ESP"},
+		Currencies: []externalapi.Currency{euro}, Languages: []externalapi.Language{{ISO639_1: "pt", ISO639_2: "por", Name: "Portuguese"}}},
+	{Name: "Spain", Capital: "Madrid", Alpha2Code: "ES", Alpha3Code: "ESP", NumericCode: "724",
+		Region: "Europe", Subregion: "Southern Europe", Population: 47400000, Area: 505992,
+		Borders:    []string{"AND", "FRA", "GIB", "PRT", "MAR"},
+		Currencies: []externalapi.Currency{euro}, Languages: []externalapi.Language{spanish}},
+	{Name: "Andorra", Capital: "Andorra la Vella", Alpha2Code: "AD", Alpha3Code: "AND", NumericCode: "020",
+		Region: "Europe", Subregion: "Southern Europe", Population: 77000, Area: 468,
+		Borders:    []string{"FRA", "ESP"},
+		Currencies: []externalapi.Currency{euro}, Languages: []externalapi.Language{{ISO639_1: "ca", ISO639_2: "cat", Name: "Catalan"}}},
+	{Name: "Vatican City", Capital: "Vatican City", Alpha2Code: "VA", Alpha3Code: "VAT", NumericCode: "336",
+		Region: "Europe", Subregion: "Southern Europe", Population: 800,
+		Borders:    []string{"ITA"},
+		Currencies: []externalapi.Currency{euro}, Languages: []externalapi.Language{{ISO639_1: "la", ISO639_2: "lat", Name: "Latin"}, italian}},
+	{Name: "Poland", Capital: "Warsaw", Alpha2Code: "PL", Alpha3Code: "POL", NumericCode: "616",
+		Region: "Europe", Subregion: "Central Europe", Population: 38000000, Area: 312679,
+		Borders:    []string{"BLR", "CZE", "DEU", "LTU", "RUS", "SVK", "UKR"},
+		Currencies: []externalapi.Currency{{Code: "PLN", Name: "Polish złoty", Symbol: "zł"}},
+		Languages:  []externalapi.Language{{ISO639_1: "pl", ISO639_2: "pol", Name: "Polish"}}},
+	{Name: "Russia", Capital: "Moscow", Alpha2Code: "RU", Alpha3Code: "RUS", NumericCode: "643",
+		Region: "Europe", Subregion: "Eastern Europe", Population: 144000000, Area: 17124442,
+		Borders:    []string{"AZE", "BLR", "CHN", "EST", "FIN", "GEO", "KAZ", "PRK", "LVA", "LTU", "MNG", "NOR", "POL", "UKR"},
+		Currencies: []externalapi.Currency{{Code: "RUB", Name: "Russian ruble", Symbol: "₽"}},
+		Languages:  []externalapi.Language{{ISO639_1: "ru", ISO639_2: "rus", Name: "Russian"}}},
+	{Name: "Georgia", Capital: "Tbilisi", Alpha2Code: "GE", Alpha3Code: "GEO", NumericCode: "268",
+		Region: "Asia", Subregion: "Western Asia", Population: 3700000, Area: 69700,
+		Borders:    []string{"ARM", "AZE", "RUS", "TUR"},
+		Currencies: []externalapi.Currency{{Code: "GEL", Name: "Georgian lari", Symbol: "₾"}},
+		Languages:  []externalapi.Language{{ISO639_1: "ka", ISO639_2: "kat", Name: "Georgian"}}},
+	{Name: "Armenia", Capital: "Yerevan", Alpha2Code: "AM", Alpha3Code: "ARM", NumericCode: "051",
+		Region: "Asia", Subregion: "Western Asia", Population: 2800000, Area: 29743,
+		Borders:    []string{"AZE", "GEO", "IRN", "TUR"},
+		Currencies: []externalapi.Currency{{Code: "AMD", Name: "Armenian dram", Symbol: "֏"}},
+		Languages:  []externalapi.Language{{ISO639_1: "hy", ISO639_2: "hye", Name: "Armenian"}}},
+	{Name: "China", Capital: "Beijing", Alpha2Code: "CN", Alpha3Code: "CHN", NumericCode: "156",
+		Region: "Asia", Subregion: "Eastern Asia", Population: 1412000000, Area: 9706961,
+		Borders:    []string{"AFG", "BTN", "MMR", "HKG", "IND", "KAZ", "PRK", "KGZ", "LAO", "MAC", "MNG", "PAK", "RUS", "TJK", "VNM", "NPL"},
+		Currencies: []externalapi.Currency{{Code: "CNY", Name: "Chinese yuan", Symbol: "¥"}},
+		Languages:  []externalapi.Language{{ISO639_1: "zh", ISO639_2: "zho", Name: "Chinese"}}},
+	{Name: "Philippines", Capital: "Manila", Alpha2Code: "PH", Alpha3Code: "PHL", NumericCode: "608",
+		Region: "Asia", Subregion: "South-Eastern Asia", Population: 115500000, Area: 342353,
+		Currencies: []externalapi.Currency{{Code: "PHP", Name: "Philippine peso", Symbol: "₱"}},
+		Languages:  []externalapi.Language{english, {ISO639_1: "tl", ISO639_2: "tgl", Name: "Filipino"}}},
+	{Name: "Chad", Capital: "N'Djamena", Alpha2Code: "TD", Alpha3Code: "TCD", NumericCode: "148",
+		Region: "Africa", Subregion: "Middle Africa", Population: 16000000, Area: 1284000,
+		Borders:    []string{"CMR", "CAF", "LBY", "NER", "NGA", "SDN"},
+		Currencies: []externalapi.Currency{{Code: "XAF", Name: "Central African CFA franc", Symbol: "Fr"}},
+		Languages:  []externalapi.Language{{ISO639_1: "ar", ISO639_2: "ara", Name: "Arabic"}, french}},
+	{Name: "Niger", Capital: "Niamey", Alpha2Code: "NE", Alpha3Code: "NER", NumericCode: "562",
+		Region: "Africa", Subregion: "Western Africa", Population: 25000000, Area: 1267000,
+		Borders:    []string{"DZA", "BEN", "BFA", "TCD", "LBY", "MLI", "NGA"},
+		Currencies: []externalapi.Currency{{Code: "XOF", Name: "West African CFA franc", Symbol: "Fr"}},
+		Languages:  []externalapi.Language{french}},
+	{Name: "Nigeria", Capital: "Abuja", Alpha2Code: "NG", Alpha3Code: "NGA", NumericCode: "566",
+		Region: "Africa", Subregion: "Western Africa", Population: 218000000, Area: 923768,
+		Borders:    []string{"BEN", "CMR", "TCD", "NER"},
+		Currencies: []externalapi.Currency{{Code: "NGN", Name: "Nigerian naira", Symbol: "₦"}},
+		Languages:  []externalapi.Language{english}},
+	{Name: "Canada", Capital: "Ottawa", Alpha2Code: "CA", Alpha3Code: "CAN", NumericCode: "124",
+		Region: "Americas", Subregion: "North America", Population: 38000000, Area: 9984670,
+		Borders:    []string{"USA"},
+		Currencies: []externalapi.Currency{{Code: "CAD", Name: "Canadian dollar", Symbol: "$"}},
+		Languages:  []externalapi.Language{english, french}},
+	{Name: "Panama", Capital: "Panama City", Alpha2Code: "PA", Alpha3Code: "PAN", NumericCode: "591",
+		Region: "Americas", Subregion: "Central America", Population: 4300000, Area: 75417,
+		Borders:    []string{"COL", "CRI"},
+		Currencies: []externalapi.Currency{{Code: "PAB", Name: "Balboa", Symbol: "B/."}, {Code: "USD", Name: "Dollar", Symbol: "$"}},
+		Languages:  []externalapi.Language{spanish},
+		Flags:      externalapi.Flags{PNG: "https://flags.example/pa.png"}},
+	{Name: "Chile", Capital: "Santiago", Alpha2Code: "CL", Alpha3Code: "CHL", NumericCode: "152",
+		Region: "Americas", Subregion: "South America", Population: 19500000, Area: 756102,
+		Borders:    []string{"ARG", "BOL", "PER"},
+		Currencies: []externalapi.Currency{{Code: "CLP", Name: "Peso", Symbol: "$"}},
+		Languages:  []externalapi.Language{spanish}},
+	{Name: "Australia", Capital: "Canberra", Alpha2Code: "AU", Alpha3Code: "AUS", NumericCode: "036",
+		Region: "Oceania", Subregion: "Australia and New Zealand", Population: 25700000, Area: 7692024,
+		Currencies: []externalapi.Currency{{Code: "AUD", Name: "Australian dollar", Symbol: "$"}},
+		Languages:  []externalapi.Language{english}},
+}
+
+// serve sends a request through s's routes. header lists header names and
+// values in turn.
+func serve(t *testing.T, s *Server, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, req)
+	return rr
+}
+
+// fetch serves a request and decodes the response into a T when it is
+// JSON, whatever its status.
+func fetch[T any](t *testing.T, s *Server, method, target, body string) (*httptest.ResponseRecorder, T) {
+	t.Helper()
+	rr := serve(t, s, method, target, body)
+	var resp T
+	if mediaType, _, _ := mime.ParseMediaType(rr.Header().Get("Content-Type")); mediaType == "application/json" {
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	}
+	return rr, resp
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/match"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 250
+)
+
+// sortField orders countries by one field, named as in the JSON record.
+// Exactly one of text and number is set; text is compared normalized, by
+// code point.
+type sortField struct {
+	text   func(externalapi.Country) string
+	number func(externalapi.Country) float64
+}
+
+var sortFields = map[string]sortField{
+	"name":         {text: func(c externalapi.Country) string { return c.Name }},
+	"native_name":  {text: func(c externalapi.Country) string { return c.NativeName }},
+	"alpha2_code":  {text: func(c externalapi.Country) string { return c.Alpha2Code }},
+	"alpha3_code":  {text: func(c externalapi.Country) string { return c.Alpha3Code }},
+	"numeric_code": {text: func(c externalapi.Country) string { return c.NumericCode }},
+	"capital":      {text: func(c externalapi.Country) string { return c.Capital }},
+	"region":       {text: func(c externalapi.Country) string { return c.Region }},
+	"subregion":    {text: func(c externalapi.Country) string { return c.Subregion }},
+	"demonym":      {text: func(c externalapi.Country) string { return c.Demonym }},
+	"population":   {number: func(c externalapi.Country) float64 { return float64(c.Population) }},
+	"area":         {number: func(c externalapi.Country) float64 { return c.Area }},
+}
+
+// sortKey is a country's position in a sort order. Ties are broken by
+// alpha-3 code, so every order is total and pages never overlap.
+type sortKey struct {
+	Text   string  `json:"t,omitempty"`
+	Number float64 `json:"n,omitempty"`
+	ID     string  `json:"id"`
+}
+
+type listOrder struct {
+	param      string // as given, e.g. "-population"
+	field      sortField
+	descending bool
+}
+
+func parseOrder(param string) (listOrder, error) {
+	if param == "" {
+		param = "name"
+	}
+	name, descending := strings.CutPrefix(param, "-")
+	field, ok := sortFields[name]
+	if !ok {
+		return listOrder{}, fmt.Errorf("cannot sort by %q", name)
+	}
+	return listOrder{param: param, field: field, descending: descending}, nil
+}
+
+func (o listOrder) key(c externalapi.Country) sortKey {
+	k := sortKey{ID: c.Alpha3Code}
+	if o.field.number != nil {
+		k.Number = o.field.number(c)
+	} else {
+		k.Text = match.Normalize(o.field.text(c))
+	}
+	return k
+}
+
+func (o listOrder) compare(a, b sortKey) int {
+	c := cmp.Or(cmp.Compare(a.Number, b.Number), strings.Compare(a.Text, b.Text))
+	if o.descending {
+		c = -c
+	}
+	return cmp.Or(c, strings.Compare(a.ID, b.ID))
+}
+
+// listCursor marks the last country of a page. It carries the sort it was
+// issued for, since the same key means something else in another order.
+type listCursor struct {
+	Sort  string  `json:"s"`
+	After sortKey `json:"a"`
+}
+
+func (c listCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (listCursor, error) {
+	var c listCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(raw, &c)
+	}
+	if err != nil {
+		return listCursor{}, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// listFilter keeps the countries matching every filter given.
+type listFilter struct {
+	region, subregion, currency, language string
+	minPopulation, maxPopulation          *int
+}
+
+func parseFilter(q url.Values) (listFilter, error) {
+	f := listFilter{
+		region:    strings.TrimSpace(q.Get("region")),
+		subregion: strings.TrimSpace(q.Get("subregion")),
+		currency:  strings.TrimSpace(q.Get("currency")),
+		language:  strings.TrimSpace(q.Get("language")),
+	}
+	for param, bound := range map[string]**int{"min_population": &f.minPopulation, "max_population": &f.maxPopulation} {
+		v := q.Get(param)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return listFilter{}, fmt.Errorf("%s must be a non-negative integer", param)
+		}
+		*bound = &n
+	}
+	return f, nil
+}
+
+func (f listFilter) match(c externalapi.Country) bool {
+	if f.region != "" && !strings.EqualFold(c.Region, f.region) {
+		return false
+	}
+	if f.subregion != "" && !strings.EqualFold(c.Subregion, f.subregion) {
+		return false
+	}
+	if f.minPopulation != nil && c.Population < *f.minPopulation {
+		return false
+	}
+	if f.maxPopulation != nil && c.Population > *f.maxPopulation {
+		return false
+	}
+	if f.currency != "" && !slices.ContainsFunc(c.Currencies, func(cur externalapi.Currency) bool {
+		return strings.EqualFold(cur.Code, f.currency)
+	}) {
+		return false
+	}
+	if f.language != "" && !slices.ContainsFunc(c.Languages, func(l externalapi.Language) bool {
+		return strings.EqualFold(l.ISO639_1, f.language) ||
+			strings.EqualFold(l.ISO639_2, f.language) ||
+			strings.EqualFold(l.Name, f.language)
+	}) {
+		return false
+	}
+	return true
+}
+
+type listResponse struct {
+	Countries  []externalapi.Country `json:"countries"`
+	Total      int                   `json:"total"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// ListCountriesHandler pages through the whole dataset, filtered and
+// sorted by the query parameters. Pages continue from an opaque cursor
+// rather than an offset, so they stay consistent while the dataset is
+// resynced.
+func (s *Server) ListCountriesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter, err := parseFilter(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	order, err := parseOrder(q.Get("sort"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fields, ok := parseFields(w, r, countryType)
+	if !ok {
+		return
+	}
+	limit := defaultPageSize
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxPageSize), http.StatusBadRequest)
+			return
+		}
+	}
+	var after *sortKey
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err == nil && cursor.Sort != order.param {
+			err = fmt.Errorf("cursor was issued for sort=%s", cursor.Sort)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		after = &cursor.After
+	}
+
+	idx, err := s.countryIndex(r.Context())
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+
+	type keyed struct {
+		country externalapi.Country
+		key     sortKey
+	}
+	var matched []keyed
+	for _, c := range idx.All() {
+		if filter.match(c) {
+			matched = append(matched, keyed{country: c, key: order.key(c)})
+		}
+	}
+	slices.SortFunc(matched, func(a, b keyed) int { return order.compare(a.key, b.key) })
+
+	start := 0
+	if after != nil {
+		start, _ = slices.BinarySearchFunc(matched, *after, func(k keyed, after sortKey) int {
+			if order.compare(k.key, after) <= 0 {
+				return -1
+			}
+			return 1
+		})
+	}
+	end := min(start+limit, len(matched))
+
+	resp := listResponse{Countries: make([]externalapi.Country, 0, end-start), Total: len(matched)}
+	for _, k := range matched[start:end] {
+		resp.Countries = append(resp.Countries, k.country)
+	}
+
+	links := []string{pageLink(r.URL, "", "first")}
+	if end < len(matched) {
+		resp.NextCursor = listCursor{Sort: order.param, After: matched[end-1].key}.encode()
+		links = append(links, pageLink(r.URL, resp.NextCursor, "next"))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+	writeFields(w, resp, fields, "countries")
+}
+
+// pageLink formats an RFC 8288 link to the page starting after cursor,
+// keeping the request's other parameters.
+func pageLink(u *url.URL, cursor, rel string) string {
+	q := u.Query()
+	if cursor == "" {
+		q.Del("cursor")
+	} else {
+		q.Set("cursor", cursor)
+	}
+	page := url.URL{Path: u.Path, RawQuery: q.Encode()}
+	return fmt.Sprintf(`<%s>; rel="%s"`, page.String(), rel)
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func names(countries []externalapi.Country) []string {
+	var out []string
+	for _, c := range countries {
+		out = append(out, c.Name)
+	}
+	return out
+}
+
+func TestListCountriesHandler_SortsByNameByDefault(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr, resp := fetch[listResponse](t, s, "GET", "/api/countries", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"Andorra", "Armenia", "Australia", "Belgium", "Canada", "Chad", "Chile", "China",
+		"France", "Georgia", "Germany", "Iceland", "Niger", "Nigeria", "Panama", "Philippines", "Poland", "Portugal",
+		"Russia", "Spain", "Switzerland", "Vatican City", "Åland Islands"}, names(resp.Countries), "by code point")
+	assert.Equal(t, len(testCountries), resp.Total)
+	assert.Empty(t, resp.NextCursor)
+	assert.Equal(t, `</api/countries>; rel="first"`, rr.Header().Get("Link"))
+}
+
+func TestListCountriesHandler_Filters(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	tests := map[string][]string{
+		"?region=europe": {"Andorra", "Belgium", "France", "Germany", "Iceland", "Poland", "Portugal",
+			"Russia", "Spain", "Switzerland", "Vatican City", "Åland Islands"},
+		"?subregion=Western%20Europe&currency=eur": {"Belgium", "France", "Germany"},
+		"?language=fr":                     {"Belgium", "Canada", "Chad", "France", "Niger", "Switzerland"},
+		"?language=French&region=Americas": {"Canada"},
+		"?min_population=100000000":        {"China", "Nigeria", "Philippines", "Russia"},
+		"?region=Europe&min_population=1000000&max_population=20000000": {"Belgium", "Portugal", "Switzerland"},
+		"?currency=JPY": nil,
+	}
+	for query, want := range tests {
+		rr, resp := fetch[listResponse](t, s, "GET", "/api/countries"+query, "")
+		require.Equal(t, http.StatusOK, rr.Code, query)
+		assert.Equal(t, want, names(resp.Countries), query)
+		assert.Equal(t, len(want), resp.Total, query)
+	}
+}
+
+func TestListCountriesHandler_Sorts(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	_, resp := fetch[listResponse](t, s, "GET", "/api/countries?sort=-population&limit=3", "")
+	assert.Equal(t, []string{"China", "Nigeria", "Russia"}, names(resp.Countries))
+
+	_, resp = fetch[listResponse](t, s, "GET", "/api/countries?sort=area&limit=2", "")
+	assert.Equal(t, []string{"Vatican City", "Andorra"}, names(resp.Countries))
+
+	_, resp = fetch[listResponse](t, s, "GET", "/api/countries?sort=-region&language=fr", "")
+	assert.Equal(t, []string{"BEL", "CHE", "FRA", "CAN", "NER", "TCD"}, codes(resp.Countries), "ties keep alpha-3 order")
+}
+
+func codes(countries []externalapi.Country) []string {
+	var out []string
+	for _, c := range countries {
+		out = append(out, c.Alpha3Code)
+	}
+	return out
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>; rel="next"`)
+
+func TestListCountriesHandler_PagesWithCursor(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	var seen []string
+	query := "?sort=-population&limit=5&region=Europe"
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5, "pagination does not end")
+		rr, resp := fetch[listResponse](t, s, "GET", "/api/countries"+query, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, 12, resp.Total)
+		seen = append(seen, names(resp.Countries)...)
+
+		m := nextLink.FindStringSubmatch(rr.Header().Get("Link"))
+		if m == nil {
+			assert.Empty(t, resp.NextCursor)
+			break
+		}
+		assert.Contains(t, m[1], "cursor="+resp.NextCursor)
+		assert.Contains(t, m[1], "region=Europe", "filters are kept")
+		query = strings.TrimPrefix(m[1], "/api/countries")
+	}
+	assert.Equal(t, []string{"Russia", "Germany", "France", "Spain", "Poland", "Belgium", "Portugal",
+		"Switzerland", "Iceland", "Andorra", "Åland Islands", "Vatican City"}, seen)
+}
+
+func TestListCountriesHandler_CursorSurvivesDatasetChanges(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	_, first := fetch[listResponse](t, s, "GET", "/api/countries?limit=2", "")
+	require.Equal(t, []string{"Andorra", "Armenia"}, names(first.Countries))
+
+	// Armenia, the last country on the page, disappears before the next one.
+	changed := []externalapi.Country{{Name: "Algeria", Alpha3Code: "DZA"}, {Name: "Aruba", Alpha3Code: "ABW"}}
+	for _, c := range testCountries {
+		if c.Name != "Armenia" {
+			changed = append(changed, c)
+		}
+	}
+	s2, _ := syncedServer(t, changed...)
+	_, second := fetch[listResponse](t, s2, "GET", "/api/countries?limit=2&cursor="+first.NextCursor, "")
+
+	assert.Equal(t, []string{"Aruba", "Australia"}, names(second.Countries))
+}
+
+func TestListCountriesHandler_BadParameters(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+	_, page := fetch[listResponse](t, s, "GET", "/api/countries?limit=1", "")
+
+	for _, query := range []string{
+		"?sort=flags",
+		"?sort=-",
+		"?limit=0",
+		"?limit=1000",
+		"?limit=ten",
+		"?min_population=-1",
+		"?max_population=lots",
+		"?cursor=not-a-cursor",
+		"?sort=-area&cursor=" + page.NextCursor,
+	} {
+		rr := serve(t, s, "GET", "/api/countries"+query, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestListCountriesHandler_FetchesListWithoutSyncedIndex(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: testCountries}
+	s.provider = stub
+
+	serve(t, s, "GET", "/api/countries?limit=2", "")
+	_, resp := fetch[listResponse](t, s, "GET", "/api/countries?region=Africa", "")
+
+	assert.Equal(t, []string{"Chad", "Niger", "Nigeria"}, names(resp.Countries))
+	assert.Equal(t, 1, stub.calls)
+}
@@ -0,0 +1,270 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/index"
+	"CountrySearch/internal/ratelimit"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// cacheEntry wraps values the lookups store in the cache. Entries past
+// their expiry are refreshed from the provider but kept around as a stale
+// fallback for when the upstream is failing.
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+func (e cacheEntry) fresh(now time.Time) bool {
+	return e.expires.IsZero() || now.Before(e.expires)
+}
+
+// countryFromV1 upgrades a value stored in the v1 shape. Only the v1
+// fields are known, so the currency is just a symbol.
+func countryFromV1(v externalapi.CountrySearchResponse) externalapi.Country {
+	country := externalapi.Country{
+		Name:       v.Name,
+		Capital:    v.Capital,
+		Population: v.Population,
+	}
+	if v.Currency != "" {
+		country.Currencies = []externalapi.Currency{{Symbol: v.Currency}}
+	}
+	return country
+}
+
+func cacheKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// candidatesKey is the cache key for the full candidate list of a search,
+// kept apart from the exact match stored under cacheKey.
+func candidatesKey(name string) string {
+	return "candidates:" + cacheKey(name)
+}
+
+// allCountriesKey names the index over the provider's whole dataset in
+// logs.
+const allCountriesKey = "countries:all"
+
+// localIndex returns the synced dataset, or nil while none is loaded.
+func (s *Server) localIndex() *index.Index {
+	if s.syncer == nil {
+		return nil
+	}
+	return s.syncer.Index()
+}
+
+func (s *Server) lookupCountry(ctx context.Context, name string) (externalapi.Country, error) {
+	country, _, err := s.lookupCountryEntry(ctx, name)
+	return country, err
+}
+
+// lookupCountryEntry is lookupCountry that also returns the fresh cache
+// entry the country came from. There is none for the synced index, or for
+// stale or offline data.
+func (s *Server) lookupCountryEntry(ctx context.Context, name string) (externalapi.Country, *cacheEntry, error) {
+	if idx := s.localIndex(); idx != nil {
+		country, ok := idx.Lookup(name)
+		if !ok {
+			return externalapi.Country{}, nil, externalapi.ErrCountryNotFound
+		}
+		return country, nil, nil
+	}
+
+	value, entry, err := s.cachedFetch(ctx, cacheKey(name), func(ctx context.Context, p externalapi.CountryProvider) (any, error) {
+		return externalapi.FetchCountry(ctx, p, name)
+	})
+	if err != nil {
+		return externalapi.Country{}, nil, err
+	}
+
+	switch v := value.(type) {
+	case externalapi.Country:
+		return v, entry, nil
+	case externalapi.CountrySearchResponse:
+		return countryFromV1(v), entry, nil
+	}
+	return externalapi.Country{}, nil, externalapi.ErrCountryNotFound
+}
+
+// countryIndex returns the synced index or, while none is loaded, one
+// over the provider's full list, refreshed every indexTTL.
+func (s *Server) countryIndex(ctx context.Context) (*index.Index, error) {
+	idx, _, err := s.countryIndexEntry(ctx)
+	return idx, err
+}
+
+// countryIndexEntry is countryIndex that also returns the fresh entry the
+// index over the full list came from, as lookupCountryEntry does.
+func (s *Server) countryIndexEntry(ctx context.Context) (*index.Index, *cacheEntry, error) {
+	if idx := s.localIndex(); idx != nil {
+		return idx, nil, nil
+	}
+
+	cached := s.countries.Load()
+	value, entry, err := s.refresh(ctx, allCountriesKey, cached, s.indexTTL, func(ctx context.Context, p externalapi.CountryProvider) (any, error) {
+		countries, err := p.ListCountries(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return index.New(countries), nil
+	})
+	if entry != nil {
+		s.countries.Store(entry)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idx, ok := value.(*index.Index)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected %T stored as %q", value, allCountriesKey)
+	}
+	return idx, backing(cached, entry), nil
+}
+
+// lookupByCode finds a country by an ISO 3166-1 code already normalized
+// with index.ParseCode.
+func (s *Server) lookupByCode(ctx context.Context, code string) (externalapi.Country, error) {
+	country, _, err := s.lookupByCodeEntry(ctx, code)
+	return country, err
+}
+
+// lookupByCodeEntry is lookupByCode that also returns the fresh entry the
+// country came from, as lookupCountryEntry does.
+func (s *Server) lookupByCodeEntry(ctx context.Context, code string) (externalapi.Country, *cacheEntry, error) {
+	idx, entry, err := s.countryIndexEntry(ctx)
+	if err != nil {
+		return externalapi.Country{}, nil, err
+	}
+
+	country, ok := idx.ByCode(code)
+	if !ok {
+		return externalapi.Country{}, nil, fmt.Errorf("%w: no country has code %s", externalapi.ErrCountryNotFound, code)
+	}
+	return country, entry, nil
+}
+
+// searchCandidates returns everything the provider matched for name.
+func (s *Server) searchCandidates(ctx context.Context, name string) ([]externalapi.Country, error) {
+	if idx := s.localIndex(); idx != nil {
+		return idx.Search(name), nil
+	}
+
+	value, _, err := s.cachedFetch(ctx, candidatesKey(name), func(ctx context.Context, p externalapi.CountryProvider) (any, error) {
+		return p.SearchCountries(ctx, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	countries, _ := value.([]externalapi.Country)
+	return countries, nil
+}
+
+// cachedFetch returns the value cached under key, fetching it from the
+// provider when there is none or it has expired. Values stored without a
+// cacheEntry never expire, and an upstream max-age overrides the cache TTL.
+// If the provider fails, a stale entry is served, and failing that the
+// offline dataset. The fresh entry the value came from is returned too,
+// or nil for stale and offline data.
+func (s *Server) cachedFetch(ctx context.Context, key string, fetch func(context.Context, externalapi.CountryProvider) (any, error)) (any, *cacheEntry, error) {
+	var cached *cacheEntry
+	if value, ok := s.cache.Get(key); ok {
+		entry, ok := value.(cacheEntry)
+		if !ok {
+			return value, &cacheEntry{value: value}, nil
+		}
+		cached = &entry
+	}
+
+	value, entry, err := s.refresh(ctx, key, cached, s.cacheTTL, fetch)
+	if entry != nil {
+		s.cache.Set(key, *entry)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, backing(cached, entry), nil
+}
+
+// backing returns the fresh entry refresh answered from: the one it
+// fetched, or cached while still fresh. Stale and offline values have
+// none.
+func backing(cached, fetched *cacheEntry) *cacheEntry {
+	if fetched != nil {
+		return fetched
+	}
+	if cached != nil && cached.fresh(time.Now()) {
+		return cached
+	}
+	return nil
+}
+
+// refresh returns the value of cached while it is fresh, and otherwise
+// fetches it again, along with the entry to store for ttl or the upstream's
+// max-age. If the provider fails, cached is served stale, and failing that
+// the offline dataset, with no new entry.
+func (s *Server) refresh(ctx context.Context, key string, cached *cacheEntry, ttl time.Duration, fetch func(context.Context, externalapi.CountryProvider) (any, error)) (any, *cacheEntry, error) {
+	if cached != nil && cached.fresh(time.Now()) {
+		return cached.value, nil, nil
+	}
+
+	fetchCtx, info := externalapi.WithCacheInfo(ctx)
+	var value any
+	var err error
+	if s.upstreamDown() && (cached != nil || s.offline != nil) {
+		err = fmt.Errorf("%w: every upstream is failing its health probes", externalapi.ErrUnavailable)
+	} else {
+		value, err = fetch(fetchCtx, s.provider)
+	}
+	if err != nil {
+		if !s.canFallBack(err) {
+			return nil, nil, err
+		}
+		if cached != nil {
+			log.Printf("serving stale data for %q: %v", key, err)
+			return cached.value, nil, nil
+		}
+		if s.offline != nil {
+			if value, offlineErr := fetch(ctx, s.offline); offlineErr == nil {
+				log.Printf("serving offline data for %q: %v", key, err)
+				return value, nil, nil
+			}
+		}
+		return nil, nil, err
+	}
+
+	entry := &cacheEntry{value: value}
+	switch {
+	case info.HasMaxAge:
+		entry.expires = time.Now().Add(info.MaxAge)
+	case ttl > 0:
+		entry.expires = time.Now().Add(ttl)
+	}
+	return value, entry, nil
+}
+
+// upstreamDown reports whether the prober has every upstream failing.
+func (s *Server) upstreamDown() bool {
+	return s.prober != nil && !s.prober.AnyHealthy()
+}
+
+// canFallBack reports whether stale or offline data may stand in for a
+// failed fetch. In the limiter's fail mode an exhausted budget is surfaced
+// as is.
+func (s *Server) canFallBack(err error) bool {
+	if errors.Is(err, externalapi.ErrCountryNotFound) {
+		return false
+	}
+	if s.limiter != nil && s.limiter.Mode() == ratelimit.ModeFail && ratelimit.IsLimited(err) {
+		return false
+	}
+	return true
+}
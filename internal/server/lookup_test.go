@@ -0,0 +1,444 @@
+package server
+
+import (
+	"CountrySearch/internal/breaker"
+	"CountrySearch/internal/cache"
+	"CountrySearch/internal/datasync"
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/probe"
+	"CountrySearch/internal/ratelimit"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProvider answers from a fixed list, matching names by substring
+// the way the upstream does, or with err when set.
+type stubProvider struct {
+	name      string
+	countries []externalapi.Country
+	err       error
+	calls     int
+}
+
+func (p *stubProvider) Name() string {
+	if p.name == "" {
+		return "stub"
+	}
+	return p.name
+}
+
+func (p *stubProvider) SearchCountries(ctx context.Context, name string) ([]externalapi.Country, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	var found []externalapi.Country
+	for _, c := range p.countries {
+		if strings.Contains(strings.ToLower(c.Name), strings.ToLower(name)) {
+			found = append(found, c)
+		}
+	}
+	if len(found) == 0 {
+		return nil, externalapi.ErrCountryNotFound
+	}
+	return found, nil
+}
+
+func (p *stubProvider) ListCountries(ctx context.Context) ([]externalapi.Country, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.countries, nil
+}
+
+func TestCountryIndex_SurvivesCacheEviction(t *testing.T) {
+	s := setupTestServer()
+	s.cache = cache.NewLRUCache(2)
+	stub := &stubProvider{countries: []externalapi.Country{
+		{Name: "Chile", Alpha3Code: "CHL"},
+		{Name: "Peru", Alpha3Code: "PER"},
+		{Name: "Bolivia", Alpha3Code: "BOL"},
+	}}
+	s.provider = stub
+
+	_, err := s.countryIndex(context.Background())
+	require.NoError(t, err)
+	for _, name := range []string{"Chile", "Peru", "Bolivia"} {
+		_, err := s.lookupCountry(context.Background(), name)
+		require.NoError(t, err)
+	}
+	idx, err := s.countryIndex(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, idx.All(), 3)
+	assert.Equal(t, 4, stub.calls, "one list call, then one search per name")
+}
+
+func TestCountryIndex_ExpiresAfterIndexTTL(t *testing.T) {
+	s := setupTestServer()
+	s.indexTTL = time.Hour
+	stub := &stubProvider{countries: []externalapi.Country{{Name: "Chile", Alpha3Code: "CHL"}}}
+	s.provider = stub
+
+	_, err := s.countryIndex(context.Background())
+	require.NoError(t, err)
+	_, err = s.countryIndex(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stub.calls)
+
+	s.countries.Store(&cacheEntry{value: s.countries.Load().value, expires: time.Now().Add(-time.Second)})
+	_, err = s.countryIndex(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, stub.calls)
+}
+
+func TestLookupCountry_CachesProviderResult(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: []externalapi.Country{
+		{Name: "Chile", Capital: "Santiago"},
+	}}
+	s.provider = stub
+
+	for i := 0; i < 3; i++ {
+		country, err := s.lookupCountry(context.Background(), "Chile")
+		require.NoError(t, err)
+		assert.Equal(t, "Santiago", country.Capital)
+	}
+	assert.Equal(t, 1, stub.calls)
+}
+
+func TestLookupCountry_RefreshesExpiredEntry(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: []externalapi.Country{
+		{Name: "Chile", Capital: "Santiago"},
+	}}
+	s.provider = stub
+	s.cache.Set("chile", cacheEntry{
+		value:   externalapi.Country{Name: "Chile", Capital: "Old"},
+		expires: time.Now().Add(-time.Minute),
+	})
+
+	country, err := s.lookupCountry(context.Background(), "Chile")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Santiago", country.Capital)
+	assert.Equal(t, 1, stub.calls)
+}
+
+func TestLookupCountry_ServesStaleWhenUpstreamFails(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{err: fmt.Errorf("%w: %w", externalapi.ErrUnavailable, breaker.ErrOpen)}
+	s.cache.Set("chile", cacheEntry{
+		value:   externalapi.Country{Name: "Chile", Capital: "Santiago"},
+		expires: time.Now().Add(-time.Minute),
+	})
+
+	country, err := s.lookupCountry(context.Background(), "chile")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Santiago", country.Capital)
+}
+
+func TestLookupCountry_InvalidUpstreamDataServesStale(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{err: fmt.Errorf("%w: all 1 records rejected", externalapi.ErrInvalidResponse)}
+	s.cache.Set("chile", cacheEntry{
+		value:   externalapi.Country{Name: "Chile", Capital: "Santiago"},
+		expires: time.Now().Add(-time.Minute),
+	})
+
+	country, err := s.lookupCountry(context.Background(), "chile")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Santiago", country.Capital)
+}
+
+func TestSearchCountryHandler_UnavailableWithoutStaleData(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{err: fmt.Errorf("%w: %w", externalapi.ErrUnavailable, breaker.ErrOpen)}
+
+	req := httptest.NewRequest("GET", "/api/countries/search?name=chile", nil)
+	rr := httptest.NewRecorder()
+	s.SearchCountryHandler(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestSearchCountryHandler_BadUpstreamResponse(t *testing.T) {
+	for _, err := range []error{
+		fmt.Errorf("%w: all 3 records rejected", externalapi.ErrInvalidResponse),
+		fmt.Errorf("%w: api returned status 500", externalapi.ErrUpstreamFailed),
+	} {
+		s := setupTestServer()
+		s.provider = &stubProvider{err: err}
+
+		req := httptest.NewRequest("GET", "/api/countries/search?name=chile", nil)
+		rr := httptest.NewRecorder()
+		s.SearchCountryHandler(rr, req)
+
+		assert.Equal(t, http.StatusBadGateway, rr.Code, err.Error())
+	}
+}
+
+func TestSearchCountryHandler_UpstreamRefusesTheRequest(t *testing.T) {
+	for status, want := range map[int]int{
+		http.StatusTooManyRequests: http.StatusServiceUnavailable,
+		http.StatusForbidden:       http.StatusBadGateway,
+	} {
+		requests := 0
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(status)
+		}))
+		s := setupTestServer()
+		s.provider = externalapi.NewAPICountriesProvider(upstream.URL, upstream.Client())
+
+		rr := serve(t, s, "GET", "/api/countries/search?name=chile", "")
+		upstream.Close()
+
+		assert.Equal(t, want, rr.Code, status)
+		assert.Equal(t, 1, requests, "no suggestions are looked up after %d", status)
+	}
+}
+
+func TestSearchCountryHandler_UpstreamUnreachable(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstream.Close()
+	s := setupTestServer()
+	s.provider = externalapi.NewAPICountriesProvider(upstream.URL, upstream.Client())
+
+	rr := serve(t, s, "GET", "/api/countries/search?name=chile", "")
+
+	assert.Equal(t, http.StatusBadGateway, rr.Code)
+}
+
+func TestSearchCountryHandler_NotFound(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{}
+
+	req := httptest.NewRequest("GET", "/api/countries/search?name=atlantis", nil)
+	rr := httptest.NewRecorder()
+	s.SearchCountryHandler(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	_, cached := s.cache.Get("atlantis")
+	assert.False(t, cached, "misses must not be cached")
+}
+
+func TestSearchCountryHandler_UpstreamErrorIsNotFound(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{err: errors.New("connection reset")}
+
+	req := httptest.NewRequest("GET", "/api/countries/search?name=chile", nil)
+	rr := httptest.NewRecorder()
+	s.SearchCountryHandler(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestLookupCountry_FailModeDoesNotServeStale(t *testing.T) {
+	s := setupTestServer()
+	s.limiter = ratelimit.New(ratelimit.Config{Mode: ratelimit.ModeFail})
+	s.provider = &stubProvider{err: fmt.Errorf("%w: %w", externalapi.ErrUnavailable, ratelimit.ErrQuotaExhausted)}
+	s.cache.Set("chile", cacheEntry{
+		value:   externalapi.Country{Name: "Chile"},
+		expires: time.Now().Add(-time.Minute),
+	})
+
+	_, err := s.lookupCountry(context.Background(), "chile")
+
+	assert.ErrorIs(t, err, ratelimit.ErrQuotaExhausted)
+}
+
+func TestLookupCountry_StaleModeServesStale(t *testing.T) {
+	s := setupTestServer()
+	s.limiter = ratelimit.New(ratelimit.Config{Mode: ratelimit.ModeStale})
+	s.provider = &stubProvider{err: fmt.Errorf("%w: %w", externalapi.ErrUnavailable, ratelimit.ErrLimited)}
+	s.cache.Set("chile", cacheEntry{
+		value:   externalapi.Country{Name: "Chile"},
+		expires: time.Now().Add(-time.Minute),
+	})
+
+	country, err := s.lookupCountry(context.Background(), "chile")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Chile", country.Name)
+}
+
+func TestLookupCountry_FallsBackToOffline(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{err: errors.New("connection refused")}
+	s.offline = &stubProvider{countries: []externalapi.Country{{Name: "Chile", Capital: "Santiago"}}}
+
+	country, err := s.lookupCountry(context.Background(), "chile")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Santiago", country.Capital)
+}
+
+func TestLookupCountry_PrefersStaleOverOffline(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{err: errors.New("connection refused")}
+	offline := &stubProvider{countries: []externalapi.Country{{Name: "Chile", Capital: "Offline"}}}
+	s.offline = offline
+	s.cache.Set("chile", cacheEntry{
+		value:   externalapi.Country{Name: "Chile", Capital: "Stale"},
+		expires: time.Now().Add(-time.Minute),
+	})
+
+	country, err := s.lookupCountry(context.Background(), "chile")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Stale", country.Capital)
+	assert.Equal(t, 0, offline.calls)
+}
+
+func TestLookupCountry_OfflineNotConsultedForNotFound(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{}
+	offline := &stubProvider{countries: []externalapi.Country{{Name: "Atlantis"}}}
+	s.offline = offline
+
+	_, err := s.lookupCountry(context.Background(), "atlantis")
+
+	assert.ErrorIs(t, err, externalapi.ErrCountryNotFound)
+	assert.Equal(t, 0, offline.calls)
+}
+
+// downProber returns a prober that has seen provider fail.
+func downProber(t *testing.T, provider externalapi.CountryProvider) *probe.Prober {
+	t.Helper()
+	p := probe.New(probe.Config{FailureThreshold: 1}, provider)
+	p.ProbeAll(context.Background())
+	require.False(t, p.AnyHealthy())
+	return p
+}
+
+func TestLookupCountry_SkipsUpstreamFailingProbes(t *testing.T) {
+	s := setupTestServer()
+	upstream := &stubProvider{err: errors.New("connection refused")}
+	s.provider = upstream
+	s.prober = downProber(t, upstream)
+	upstream.calls = 0
+	s.offline = &stubProvider{countries: []externalapi.Country{{Name: "Chile", Capital: "Santiago"}}}
+
+	country, err := s.lookupCountry(context.Background(), "chile")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Santiago", country.Capital)
+	assert.Equal(t, 0, upstream.calls)
+}
+
+func TestLookupCountry_TriesUpstreamFailingProbesWithoutFallback(t *testing.T) {
+	s := setupTestServer()
+	upstream := &stubProvider{err: errors.New("connection refused")}
+	s.provider = upstream
+	s.prober = downProber(t, upstream)
+	upstream.err = nil
+	upstream.countries = []externalapi.Country{{Name: "Chile", Capital: "Santiago"}}
+
+	country, err := s.lookupCountry(context.Background(), "chile")
+
+	require.NoError(t, err, "with nothing to fall back on the upstream still gets a chance")
+	assert.Equal(t, "Santiago", country.Capital)
+}
+
+func syncedServer(t *testing.T, countries ...externalapi.Country) (*Server, *stubProvider) {
+	t.Helper()
+	s := setupTestServer()
+	stub := &stubProvider{countries: countries}
+	s.provider = stub
+	s.syncer = datasync.New(stub, datasync.Config{MinCountries: 1})
+	require.NoError(t, s.syncer.Sync(context.Background()))
+	stub.calls = 0
+	return s, stub
+}
+
+func TestLookupCountry_ServedFromSyncedIndex(t *testing.T) {
+	s, stub := syncedServer(t,
+		externalapi.Country{Name: "Germany", Alpha3Code: "DEU", AltSpellings: []string{"Deutschland"}},
+		externalapi.Country{Name: "France", Alpha3Code: "FRA"},
+	)
+
+	country, err := s.lookupCountry(context.Background(), "deutschland")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Germany", country.Name)
+	assert.Equal(t, 0, stub.calls)
+}
+
+func TestLookupCountry_SyncedIndexMissIsNotFound(t *testing.T) {
+	s, stub := syncedServer(t, externalapi.Country{Name: "France", Alpha3Code: "FRA"})
+
+	_, err := s.lookupCountry(context.Background(), "Atlantis")
+
+	assert.ErrorIs(t, err, externalapi.ErrCountryNotFound)
+	assert.Equal(t, 0, stub.calls)
+}
+
+func TestSearchCandidates_ServedFromSyncedIndex(t *testing.T) {
+	s, stub := syncedServer(t,
+		externalapi.Country{Name: "Guinea", Alpha3Code: "GIN"},
+		externalapi.Country{Name: "Guinea-Bissau", Alpha3Code: "GNB"},
+		externalapi.Country{Name: "France", Alpha3Code: "FRA"},
+	)
+
+	countries, err := s.searchCandidates(context.Background(), "guinea")
+
+	require.NoError(t, err)
+	assert.Len(t, countries, 2)
+	assert.Equal(t, 0, stub.calls)
+}
+
+func TestLookupCountry_FetchesByNameUntilIndexLoads(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: []externalapi.Country{{Name: "Chile", Alpha3Code: "CHL"}}}
+	s.provider = stub
+	s.syncer = datasync.New(stub, datasync.Config{MinCountries: 1})
+
+	country, err := s.lookupCountry(context.Background(), "Chile")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Chile", country.Name)
+	assert.Equal(t, 1, stub.calls)
+}
+
+func TestLookupCountry_UpstreamMaxAgeSetsTTL(t *testing.T) {
+	var requests, notModified int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=0")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"name": "Chile", "capital": "Santiago"}]`))
+	}))
+	defer upstream.Close()
+
+	s := setupTestServer()
+	s.cacheTTL = time.Hour
+	s.provider = externalapi.NewAPICountriesProvider(upstream.URL, upstream.Client())
+
+	for i := 0; i < 3; i++ {
+		country, err := s.lookupCountry(context.Background(), "Chile")
+		require.NoError(t, err)
+		assert.Equal(t, "Santiago", country.Capital)
+	}
+
+	assert.Equal(t, 3, requests, "max-age=0 overrides the hour-long cache TTL")
+	assert.Equal(t, 2, notModified, "refreshes are revalidated")
+}
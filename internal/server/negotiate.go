@@ -0,0 +1,67 @@
+package server
+
+import (
+	"CountrySearch/internal/render"
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// negotiate sends next's JSON responses in the format the request asks
+// for, by the format query parameter or the Accept header, and answers
+// 406 when no registered encoder can produce it. Error responses are sent
+// as the handler wrote them.
+func (s *Server) negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		enc, err := render.Negotiate(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Not acceptable; formats are %s", strings.Join(render.Formats(), ", ")), http.StatusNotAcceptable)
+			return
+		}
+
+		w.Header().Set("Content-Type", enc.ContentType)
+		if enc.Format == render.JSON.Format {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+		if buf.status >= http.StatusBadRequest {
+			w.WriteHeader(buf.status)
+			_, _ = w.Write(buf.body.Bytes())
+			return
+		}
+
+		var out bytes.Buffer
+		if err := enc.Transcode(&out, buf.body.Bytes()); err != nil {
+			log.Printf("error encoding response as %s: %v", enc.Format, err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(buf.status)
+		_, _ = w.Write(out.Bytes())
+	})
+}
+
+// bufferedResponse holds a handler's response back to be re-encoded.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status, b.wroteHeader = status, true
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate_DefaultsToJSON(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "GET", "/api/countries/code/PA?fields=name", "")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+	assert.Equal(t, `{"name":"Panama"}`, rr.Body.String())
+}
+
+func TestNegotiate_WildcardAfterUnsupportedTypes(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "GET", "/api/countries/code/PA?fields=name", "", "Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `{"name":"Panama"}`, rr.Body.String())
+}
+
+func TestNegotiate_PreferredTypeBeatsWildcard(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "GET", "/api/countries/code/PA?fields=name", "", "Accept", "text/csv, */*;q=0.5")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "name\nPanama\n", rr.Body.String())
+
+	rr = serve(t, s, "GET", "/api/countries/code/PA?fields=name", "", "Accept", "application/msgpack, */*")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/msgpack", rr.Header().Get("Content-Type"))
+}
+
+func TestNegotiate_ByAcceptHeader(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "GET", "/api/countries/search?name=panama", "", "Accept", "text/html;q=0.9, application/xml")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><name>Panama</name><capital>Panama City</capital><currency>B/.</currency><population>4300000</population></response>`,
+		rr.Body.String())
+}
+
+func TestNegotiate_ByFormatParameter(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "GET", "/api/countries?region=Americas&fields=name,currencies.code&format=csv", "", "Accept", "application/json")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "name,currencies.code\nCanada,CAD\nChile,CLP\nPanama,PAB;USD\n", rr.Body.String())
+	assert.NotEmpty(t, rr.Header().Get("Link"), "the handler's headers are kept")
+
+	rr = serve(t, s, "GET", "/api/v2/countries/search?name=chile&fields=name,population&format=yaml", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/yaml", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "name: Chile\npopulation: 19500000\n", rr.Body.String())
+
+	rr = serve(t, s, "GET", "/api/countries/CL/neighbours?format=msgpack", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/msgpack", rr.Header().Get("Content-Type"))
+	assert.Equal(t, byte(0x83), rr.Body.Bytes()[0], "a map of three fields")
+}
+
+func TestNegotiate_NotAcceptable(t *testing.T) {
+	s, stub := syncedServer(t, testCountries...)
+
+	for _, rr := range []*httptest.ResponseRecorder{
+		serve(t, s, "GET", "/api/countries/search?name=panama", "", "Accept", "text/html"),
+		serve(t, s, "GET", "/api/routes?from=PA&to=CL&format=toml", ""),
+	} {
+		assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+		assert.Equal(t, "Not acceptable; formats are json, xml, csv, yaml, msgpack\n", rr.Body.String())
+	}
+	assert.Zero(t, stub.calls)
+}
+
+func TestNegotiate_ErrorsAreSentAsWritten(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "GET", "/api/countries/search?name=Panamma", "", "Accept", "application/xml")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), `"suggestions":[{"name":"Panama"`)
+
+	rr = serve(t, s, "GET", "/api/countries/autocomplete?format=yaml", "")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+}
+
+func TestNegotiate_EnrichKeepsItsOwnFormat(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	rr := serve(t, s, "POST", "/api/countries/enrich?column=country&fields=capital", "country\nChile\n", "Accept", "application/xml")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "country,capital,enrich_error\nChile,Santiago,\n", rr.Body.String())
+}
@@ -2,7 +2,10 @@ package server
 
 import (
 	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/index"
 	"encoding/json"
+	"errors"
+	"expvar"
 	"log"
 	"net/http"
 
@@ -14,9 +17,27 @@ func (s *Server) RegisterRoutes() http.Handler {
 
 	// Wrap all routes with CORS middleware
 	corsWrapper := s.corsMiddleware(r)
-	r.HandlerFunc(http.MethodGet, "/api/countries/search", s.SearchCountryHandler)
+	r.HandlerFunc(http.MethodGet, "/health", s.HealthHandler)
+	r.HandlerFunc(http.MethodGet, "/health/upstreams", s.UpstreamsHealthHandler)
+	r.Handler(http.MethodGet, "/debug/vars", expvar.Handler())
+	r.Handler(http.MethodGet, "/api/countries/search", s.negotiate(http.HandlerFunc(s.SearchCountryHandler)))
+	r.Handler(http.MethodGet, "/api/v2/countries/search", s.negotiate(http.HandlerFunc(s.SearchCountryV2Handler)))
+	r.Handler(http.MethodGet, "/api/countries", s.negotiate(http.HandlerFunc(s.ListCountriesHandler)))
+	r.Handler(http.MethodGet, "/api/countries/code/:code", s.negotiate(http.HandlerFunc(s.CountryByCodeHandler)))
+	r.Handler(http.MethodGet, "/api/countries/autocomplete", s.negotiate(http.HandlerFunc(s.AutocompleteHandler)))
+	r.Handler(http.MethodGet, "/api/countries/compare", s.negotiate(http.HandlerFunc(s.CompareHandler)))
+	r.Handler(http.MethodPost, "/api/countries/batch", s.negotiate(http.HandlerFunc(s.BatchLookupHandler)))
+	r.HandlerFunc(http.MethodPost, "/api/countries/enrich", s.EnrichHandler)
+	r.Handler(http.MethodGet, "/api/routes", s.negotiate(http.HandlerFunc(s.RouteHandler)))
 
-	return corsWrapper
+	// httprouter can't hold a wildcard beside /api/countries/search and the
+	// other fixed paths, so routes under a country go through a ServeMux
+	// in front of it.
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/countries/{code}/neighbours", s.corsMiddleware(s.negotiate(http.HandlerFunc(s.NeighboursHandler))))
+	mux.Handle("/", corsWrapper)
+
+	return mux
 }
 
 // CORS middleware
@@ -38,25 +59,110 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// SearchCountryHandler serves the v1 response shape.
 func (s *Server) SearchCountryHandler(w http.ResponseWriter, r *http.Request) {
+	fields, ok := parseFields(w, r, searchResponseType)
+	if !ok {
+		return
+	}
+	if isFuzzy(r) {
+		s.fuzzySearch(w, r, fields, func(c externalapi.Country) any { return c.SearchResponse() })
+		return
+	}
+
+	key := "v1:" + cacheKey(r.URL.Query().Get("name"))
+	if s.writeCachedProjection(w, key, fields) {
+		return
+	}
+	country, entry, ok := s.searchCountry(w, r)
+	if !ok {
+		return
+	}
+	s.writeProjection(w, key, country.SearchResponse(), fields, entry)
+}
+
+// SearchCountryV2Handler serves the full country record.
+func (s *Server) SearchCountryV2Handler(w http.ResponseWriter, r *http.Request) {
+	fields, ok := parseFields(w, r, countryType)
+	if !ok {
+		return
+	}
+	if isFuzzy(r) {
+		s.fuzzySearch(w, r, fields, func(c externalapi.Country) any { return c })
+		return
+	}
+
+	key := "v2:" + cacheKey(r.URL.Query().Get("name"))
+	if s.writeCachedProjection(w, key, fields) {
+		return
+	}
+	country, entry, ok := s.searchCountry(w, r)
+	if !ok {
+		return
+	}
+	s.writeProjection(w, key, country, fields, entry)
+}
+
+// CountryByCodeHandler serves the full record of the country with an
+// alpha-2, alpha-3 or numeric ISO 3166-1 code.
+func (s *Server) CountryByCodeHandler(w http.ResponseWriter, r *http.Request) {
+	code, err := index.ParseCode(httprouter.ParamsFromContext(r.Context()).ByName("code"))
+	if err != nil {
+		http.Error(w, "Invalid country code", http.StatusBadRequest)
+		return
+	}
+	fields, ok := parseFields(w, r, countryType)
+	if !ok {
+		return
+	}
 
+	key := "code:" + code
+	if s.writeCachedProjection(w, key, fields) {
+		return
+	}
+	country, entry, err := s.lookupByCodeEntry(r.Context(), code)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	s.writeProjection(w, key, country, fields, entry)
+}
+
+// searchCountry resolves the name query parameter, with the cache entry
+// it came from, writing an error response and returning false when it
+// can't.
+func (s *Server) searchCountry(w http.ResponseWriter, r *http.Request) (externalapi.Country, *cacheEntry, bool) {
 	name := r.URL.Query().Get("name")
-	var resp externalapi.CountrySearchResponse
-	if value, ok := s.cache.Get(name); ok {
-		if country, ok := value.(externalapi.CountrySearchResponse); ok {
-			resp = country
-		}
-	} else {
-		country, err := externalapi.FetchCountryData(name)
-		if err != nil {
-			log.Printf("error fetching country data: %v", err)
-			http.Error(w, "Country not found", http.StatusNotFound)
-			return
-		}
-		resp = country
-		s.cache.Set(name, country)
+	country, entry, err := s.lookupCountryEntry(r.Context(), name)
+	if err != nil {
+		s.writeSearchError(w, r, name, err)
+		return externalapi.Country{}, nil, false
+	}
+	return country, entry, true
+}
+
+func writeLookupError(w http.ResponseWriter, err error) {
+	log.Printf("error fetching country data: %v", err)
+	status, message := lookupStatus(err)
+	http.Error(w, message, status)
+}
+
+// lookupStatus maps a lookup error to the response status and message.
+func lookupStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, externalapi.ErrUnavailable),
+		errors.Is(err, externalapi.ErrUpstreamThrottled):
+		return http.StatusServiceUnavailable, "Country service unavailable"
+	case errors.Is(err, externalapi.ErrInvalidResponse),
+		errors.Is(err, externalapi.ErrResponseTooLarge),
+		errors.Is(err, externalapi.ErrUpstreamFailed):
+		return http.StatusBadGateway, "Country service returned a bad response"
 	}
-	jsonResp, err := json.Marshal(resp)
+	return http.StatusNotFound, "Country not found"
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	jsonResp, err := json.Marshal(v)
 	if err != nil {
 		log.Fatalf("error handling JSON marshal. Err: %v", err)
 	}
@@ -4,6 +4,7 @@ import (
 	"CountrySearch/internal/cache"
 	"CountrySearch/internal/externalapi"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -142,3 +143,114 @@ func TestSearchCountryHandler_ResponseBodyNotEmpty(t *testing.T) {
 
 	assert.NotEmpty(t, rr.Body.String())
 }
+
+func TestSearchCountryV2Handler_ReturnsFullRecord(t *testing.T) {
+	s := setupTestServer()
+	s.cache.Set("france", externalapi.Country{
+		Name:       "France",
+		Capital:    "Paris",
+		Alpha2Code: "FR",
+		Alpha3Code: "FRA",
+		Region:     "Europe",
+	})
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v2/countries/search?name=France", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response externalapi.Country
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "FRA", response.Alpha3Code)
+	assert.Equal(t, "Europe", response.Region)
+}
+
+func TestSearchCountryHandler_V1ShapeFromFullRecord(t *testing.T) {
+	s := setupTestServer()
+	s.cache.Set("france", externalapi.Country{
+		Name:       "France",
+		Capital:    "Paris",
+		Currencies: []externalapi.Currency{{Code: "EUR", Symbol: "€"}},
+		Alpha2Code: "FR",
+	})
+
+	rr := httptest.NewRecorder()
+	s.SearchCountryHandler(rr, httptest.NewRequest("GET", "/api/countries/search?name=france", nil))
+
+	assert.JSONEq(t, `{"name":"France","capital":"Paris","currency":"€","population":0}`, rr.Body.String())
+}
+
+func TestCountryByCodeHandler_AcceptsEveryCodeForm(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: testCountries}
+	s.provider = stub
+	handler := s.RegisterRoutes()
+
+	for _, code := range []string{"fr", "FRA", "fRa", "250"} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/code/"+code, nil))
+
+		require.Equal(t, http.StatusOK, rr.Code, code)
+		var response externalapi.Country
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "Paris", response.Capital, code)
+	}
+	assert.Equal(t, 1, stub.calls, "the full list is fetched once and cached")
+}
+
+func TestCountryByCodeHandler_PadsNumericCodes(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{countries: testCountries}
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/code/36", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"Canberra"`)
+}
+
+func TestCountryByCodeHandler_MalformedCode(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: testCountries}
+	s.provider = stub
+
+	for _, code := range []string{"F", "FRAN", "F1", "12345"} {
+		rr := httptest.NewRecorder()
+		s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/code/"+code, nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, code)
+	}
+	assert.Equal(t, 0, stub.calls)
+}
+
+func TestCountryByCodeHandler_UnassignedCode(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{countries: testCountries}
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/code/XK", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestCountryByCodeHandler_UsesSyncedIndex(t *testing.T) {
+	s, stub := syncedServer(t, testCountries...)
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/code/aus", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"Canberra"`)
+	assert.Equal(t, 0, stub.calls)
+}
+
+func TestCountryByCodeHandler_FallsBackToOffline(t *testing.T) {
+	s := setupTestServer()
+	s.provider = &stubProvider{err: errors.New("connection refused")}
+	s.offline = &stubProvider{countries: testCountries}
+
+	rr := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/code/FR", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"Paris"`)
+}
@@ -1,29 +1,83 @@
 package server
 
 import (
+	"CountrySearch/internal/breaker"
 	"CountrySearch/internal/cache"
+	"CountrySearch/internal/config"
+	"CountrySearch/internal/dataset"
+	"CountrySearch/internal/datasync"
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/hedge"
+	"CountrySearch/internal/httpclient"
+	"CountrySearch/internal/httpfixture"
+	"CountrySearch/internal/probe"
+	"CountrySearch/internal/ratelimit"
+	"context"
 	"fmt"
+	"log"
 	"net/http"
-	"os"
-	"strconv"
+	"sync/atomic"
 	"time"
 )
 
 type Server struct {
-	port  int
-	cache *cache.LRUCache
+	port     int
+	cache    *cache.LRUCache
+	cacheTTL time.Duration
+	// countries holds the index over the provider's full list, used while
+	// no synced one is loaded. It lives outside the cache so searches
+	// can't evict it, and expires after indexTTL.
+	countries atomic.Pointer[cacheEntry]
+	indexTTL  time.Duration
+
+	provider externalapi.CountryProvider
+	breaker  *breaker.Breaker
+	limiter  *ratelimit.Limiter
+	hedge    *hedge.Policy
+	// offline answers, as a last resort, lookups the provider failed and
+	// no stale entry could cover.
+	offline externalapi.CountryProvider
+	// syncer keeps a local copy of the provider's whole dataset. While it
+	// is loaded, lookups never reach the provider.
+	syncer *datasync.Syncer
+	// prober checks each upstream in the background. While every upstream
+	// fails its probes, lookups that have stale or offline data to fall
+	// back on skip the upstream.
+	prober *probe.Prober
+
+	// batchMaxItems and batchWorkers bound a batch lookup's size and
+	// concurrency; zero means the defaults.
+	batchMaxItems int
+	batchWorkers  int
+
+	// enrichMaxBytes caps an enrich upload; zero means the default.
+	enrichMaxBytes int
+
+	borders atomic.Pointer[indexedGraph]
 }
 
-func NewServer() *http.Server {
-	port, err := strconv.Atoi(os.Getenv("PORT"))
-	if err != nil {
-		port = 8080
-	}
-	cache := cache.NewLRUCache(100)
+// App is the HTTP server together with the background work, started by
+// Start, that keeps its data fresh.
+type App struct {
+	*http.Server
+	api *Server
+}
+
+func NewServer() *App {
+	cfg := config.Load()
+
 	NewServer := &Server{
-		port:  port,
-		cache: cache,
+		port:     cfg.Port,
+		cache:    cache.NewLRUCache(cfg.CacheCapacity),
+		cacheTTL: cfg.CacheTTL,
+		indexTTL: cfg.IndexTTL,
+
+		batchMaxItems: cfg.BatchMaxItems,
+		batchWorkers:  cfg.BatchWorkers,
+
+		enrichMaxBytes: cfg.EnrichMaxBytes,
 	}
+	NewServer.configureProviders(cfg)
 
 	// Declare Server config
 	server := &http.Server{
@@ -34,5 +88,89 @@ func NewServer() *http.Server {
 		WriteTimeout: 30 * time.Second,
 	}
 
-	return server
+	return &App{Server: server, api: NewServer}
+}
+
+// Start runs the dataset sync and upstream probes, when configured, until
+// ctx is done or the server shuts down. Until it is called nothing is
+// fetched in the background.
+func (a *App) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	a.RegisterOnShutdown(cancel)
+	if a.api.syncer != nil {
+		go a.api.syncer.Run(ctx)
+	}
+	if a.api.prober != nil {
+		go a.api.prober.Run(ctx)
+	}
+}
+
+// configureProviders builds the upstream provider and its guards from cfg.
+func (s *Server) configureProviders(cfg config.Config) {
+	if cfg.Offline {
+		s.provider = dataset.NewProvider()
+		return
+	}
+
+	client := upstreamClient(cfg)
+	upstream := externalapi.NewAPICountriesProvider(cfg.UpstreamBaseURL, client)
+	upstream.SetMaxResponseSize(cfg.UpstreamMaxResponseSize)
+	s.breaker = breaker.New(cfg.Breaker)
+	s.limiter = ratelimit.New(cfg.RateLimit)
+
+	// Both hedged attempts, and the probes, spend from the rate limit; the
+	// breaker sees only the combined answer.
+	var provider externalapi.CountryProvider = externalapi.WithRateLimit(upstream, s.limiter)
+	upstreams := []externalapi.CountryProvider{provider}
+	var hedged *externalapi.HedgedProvider
+	if cfg.HedgeEnabled {
+		var backup externalapi.CountryProvider
+		switch cfg.HedgeBackup {
+		case "":
+		case "restcountries":
+			rest := externalapi.NewRESTCountriesProvider(cfg.RESTCountriesBaseURL, client)
+			rest.SetMaxResponseSize(cfg.UpstreamMaxResponseSize)
+			backup = externalapi.WithRateLimit(rest, s.limiter)
+			upstreams = append(upstreams, backup)
+		default:
+			log.Printf("INVALID: HEDGE_BACKUP=%q is not a known provider, hedging to %s", cfg.HedgeBackup, upstream.Name())
+		}
+		s.hedge = hedge.New(cfg.Hedge)
+		hedged = externalapi.WithHedging(provider, backup, s.hedge)
+		provider = hedged
+	}
+	s.provider = externalapi.WithBreaker(provider, s.breaker)
+	if cfg.Probe.Interval > 0 {
+		s.prober = probe.New(cfg.Probe, upstreams...)
+		if hedged != nil {
+			hedged.RouteBy(s.prober.Healthy)
+		}
+	}
+	if cfg.OfflineFallback {
+		s.offline = dataset.NewProvider()
+	}
+	if cfg.Sync.Interval > 0 {
+		s.syncer = datasync.New(s.provider, cfg.Sync)
+	}
+}
+
+// upstreamClient returns the HTTP client shared by the upstream providers,
+// recording or replaying fixtures when configured to.
+func upstreamClient(cfg config.Config) *http.Client {
+	if cfg.UpstreamReplayDir != "" {
+		rep, err := httpfixture.NewReplayer(cfg.UpstreamReplayDir)
+		if err != nil {
+			log.Fatalf("error loading upstream fixtures: %v", err)
+		}
+		return &http.Client{Transport: rep}
+	}
+
+	client, err := httpclient.New(cfg.HTTPClient)
+	if err != nil {
+		log.Fatalf("error building upstream HTTP client: %v", err)
+	}
+	if cfg.UpstreamRecordDir != "" {
+		client.Transport = httpfixture.NewRecorder(cfg.UpstreamRecordDir, client.Transport)
+	}
+	return client
 }
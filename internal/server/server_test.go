@@ -2,6 +2,11 @@ package server
 
 import (
 	"CountrySearch/internal/cache"
+	"CountrySearch/internal/config"
+	"CountrySearch/internal/datasync"
+	"CountrySearch/internal/externalapi"
+	"CountrySearch/internal/probe"
+	"context"
 	"net/http"
 	"os"
 	"testing"
@@ -52,6 +57,28 @@ func TestNewServer_WithPort3000(t *testing.T) {
 	assert.Equal(t, ":3000", server.Addr)
 }
 
+func TestNewServer_LeavesBackgroundWorkToStart(t *testing.T) {
+	os.Setenv("SYNC_INTERVAL", "1h")
+	defer os.Unsetenv("SYNC_INTERVAL")
+
+	server := NewServer()
+	require.NotNil(t, server.api.syncer)
+	assert.Nil(t, server.api.syncer.Status().LastSync)
+}
+
+func TestApp_StartRunsSync(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: []externalapi.Country{{Name: "France", Alpha3Code: "FRA"}}}
+	s.syncer = datasync.New(stub, datasync.Config{Interval: time.Hour, Timeout: time.Second, MinCountries: 1})
+	app := &App{Server: &http.Server{}, api: s}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	app.Start(ctx)
+
+	assert.Eventually(t, func() bool { return s.syncer.Index() != nil }, time.Second, 10*time.Millisecond)
+}
+
 func TestRegisterRoutes_ReturnsRouter(t *testing.T) {
 	s := &Server{
 		port:  8080,
@@ -67,7 +94,7 @@ func TestNewServer_ValidHTTPServer(t *testing.T) {
 	defer os.Unsetenv("PORT")
 
 	server := NewServer()
-	assert.IsType(t, &http.Server{}, server)
+	assert.IsType(t, &http.Server{}, server.Server)
 }
 
 func TestNewServer_Port8000(t *testing.T) {
@@ -105,3 +132,81 @@ func TestNewServer_MultipleInstances(t *testing.T) {
 	require.NotNil(t, server2)
 	assert.Equal(t, server1.Addr, server2.Addr)
 }
+
+func TestConfigureProviders_Offline(t *testing.T) {
+	s := &Server{}
+	s.configureProviders(config.Config{Offline: true})
+
+	assert.Equal(t, "offline", s.provider.Name())
+	assert.Nil(t, s.breaker)
+	assert.Nil(t, s.limiter)
+}
+
+func TestConfigureProviders_UpstreamWithOfflineFallback(t *testing.T) {
+	s := &Server{}
+	s.configureProviders(config.Config{OfflineFallback: true})
+
+	assert.Equal(t, "apicountries", s.provider.Name())
+	assert.NotNil(t, s.breaker)
+	assert.NotNil(t, s.limiter)
+	require.NotNil(t, s.offline)
+	assert.Equal(t, "offline", s.offline.Name())
+}
+
+func TestConfigureProviders_SyncEnabledByInterval(t *testing.T) {
+	s := &Server{}
+	s.configureProviders(config.Config{Sync: datasync.Config{Interval: time.Hour}})
+	require.NotNil(t, s.syncer)
+	assert.Nil(t, s.syncer.Index())
+
+	s = &Server{}
+	s.configureProviders(config.Config{})
+	assert.Nil(t, s.syncer)
+
+	s = &Server{}
+	s.configureProviders(config.Config{Offline: true, Sync: datasync.Config{Interval: time.Hour}})
+	assert.Nil(t, s.syncer, "offline mode is already local")
+}
+
+func TestConfigureProviders_Hedging(t *testing.T) {
+	s := &Server{}
+	s.configureProviders(config.Config{})
+	assert.Nil(t, s.hedge)
+
+	s = &Server{}
+	s.configureProviders(config.Config{HedgeEnabled: true, HedgeBackup: "restcountries"})
+	require.NotNil(t, s.hedge)
+	assert.Equal(t, "apicountries", s.provider.Name())
+}
+
+func TestConfigureProviders_ProbesEachUpstream(t *testing.T) {
+	s := &Server{}
+	s.configureProviders(config.Config{Probe: probe.Config{Interval: time.Minute}})
+	require.NotNil(t, s.prober)
+	assert.Len(t, s.prober.Status(), 1)
+
+	s = &Server{}
+	s.configureProviders(config.Config{
+		Probe:        probe.Config{Interval: time.Minute},
+		HedgeEnabled: true,
+		HedgeBackup:  "restcountries",
+	})
+	status := s.prober.Status()
+	require.Len(t, status, 2)
+	assert.Equal(t, "apicountries", status[0].Name)
+	assert.Equal(t, "restcountries", status[1].Name)
+
+	s = &Server{}
+	s.configureProviders(config.Config{})
+	assert.Nil(t, s.prober)
+}
+
+func TestConfigureProviders_ReplaysFixtures(t *testing.T) {
+	s := &Server{}
+	s.configureProviders(config.Config{UpstreamReplayDir: "../externalapi/testdata/fixtures"})
+
+	country, err := externalapi.FetchCountry(context.Background(), s.provider, "France")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", country.Capital)
+}
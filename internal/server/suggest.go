@@ -0,0 +1,72 @@
+package server
+
+import (
+	"CountrySearch/internal/externalapi"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+)
+
+const (
+	maxSuggestions = 3
+	// minSuggestionConfidence is the lowest match.Confidence offered as a
+	// correction.
+	minSuggestionConfidence = 0.6
+)
+
+type suggestion struct {
+	Name       string  `json:"name"`
+	Matched    string  `json:"matched"`
+	Alpha3Code string  `json:"alpha3_code"`
+	Confidence float64 `json:"confidence"`
+}
+
+// notFoundResponse is returned with 404 by the search endpoints, with the
+// countries the query may have meant.
+type notFoundResponse struct {
+	Error       string       `json:"error"`
+	Query       string       `json:"query"`
+	Suggestions []suggestion `json:"suggestions"`
+}
+
+// writeSearchError answers a search for name that failed. Not-found
+// responses carry suggestions when the provider said there is no such
+// country, scored against every known name and alias.
+func (s *Server) writeSearchError(w http.ResponseWriter, r *http.Request, name string, err error) {
+	if status, _ := lookupStatus(err); status != http.StatusNotFound {
+		writeLookupError(w, err)
+		return
+	}
+	log.Printf("error fetching country data: %v", err)
+
+	resp := notFoundResponse{Error: "Country not found", Query: name, Suggestions: []suggestion{}}
+	if errors.Is(err, externalapi.ErrCountryNotFound) && strings.TrimSpace(name) != "" {
+		resp.Suggestions = s.suggest(r, name)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusNotFound)
+	writeJSON(w, resp)
+}
+
+func (s *Server) suggest(r *http.Request, name string) []suggestion {
+	suggestions := []suggestion{}
+	idx, err := s.countryIndex(r.Context())
+	if err != nil {
+		log.Printf("error loading countries to suggest corrections for %q: %v", name, err)
+		return suggestions
+	}
+
+	for _, sg := range idx.Suggest(name, maxSuggestions, minSuggestionConfidence) {
+		suggestions = append(suggestions, suggestion{
+			Name:       sg.Country.Name,
+			Matched:    sg.Matched,
+			Alpha3Code: sg.Country.Alpha3Code,
+			Confidence: math.Round(sg.Confidence*1000) / 1000,
+		})
+	}
+	return suggestions
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func searchNotFound(t *testing.T, s *Server, target string) notFoundResponse {
+	t.Helper()
+	rr, resp := fetch[notFoundResponse](t, s, "GET", target, "")
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "Country not found", resp.Error)
+	assert.NotNil(t, resp.Suggestions)
+	return resp
+}
+
+func TestSearchNotFound_Suggests(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	resp := searchNotFound(t, s, "/api/countries/search?name=Germenia")
+
+	assert.Equal(t, "Germenia", resp.Query)
+	require.Len(t, resp.Suggestions, 2)
+	assert.Equal(t, suggestion{Name: "Germany", Matched: "Germany", Alpha3Code: "DEU", Confidence: 0.813}, resp.Suggestions[0])
+	assert.Equal(t, "Armenia", resp.Suggestions[1].Name)
+	assert.Equal(t, 0.75, resp.Suggestions[1].Confidence)
+}
+
+func TestSearchNotFound_SuggestsOnEveryVersion(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	for _, target := range []string{
+		"/api/v2/countries/search?name=Filipines",
+		"/api/countries/search?name=Filipines&mode=fuzzy",
+	} {
+		resp := searchNotFound(t, s, target)
+		require.Len(t, resp.Suggestions, 1, target)
+		assert.Equal(t, "Philippines", resp.Suggestions[0].Name, target)
+	}
+}
+
+func TestSearchNotFound_NothingClose(t *testing.T) {
+	s, _ := syncedServer(t, testCountries...)
+
+	resp := searchNotFound(t, s, "/api/countries/search?name=Atlantis")
+	assert.Empty(t, resp.Suggestions)
+}
+
+func TestSearchNotFound_SuggestsFromProviderList(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{countries: testCountries}
+	s.provider = stub
+
+	resp := searchNotFound(t, s, "/api/countries/search?name=Deutchland")
+	require.Len(t, resp.Suggestions, 1)
+	assert.Equal(t, "Deutschland", resp.Suggestions[0].Matched)
+
+	searchNotFound(t, s, "/api/countries/search?name=Frence")
+	assert.Equal(t, 3, stub.calls, "the full list is fetched once")
+}
+
+func TestSearchNotFound_NoSuggestionsForUpstreamErrors(t *testing.T) {
+	s := setupTestServer()
+	stub := &stubProvider{err: errors.New("connection reset")}
+	s.provider = stub
+
+	resp := searchNotFound(t, s, "/api/countries/search?name=Germny")
+	assert.Empty(t, resp.Suggestions)
+	assert.Equal(t, 1, stub.calls)
+}